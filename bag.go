@@ -0,0 +1,107 @@
+package deepunique
+
+import (
+	"cmp"
+	"iter"
+	"maps"
+	"slices"
+)
+
+// DeepBag is a multiset of values compared by deep equality. It counts how many
+// times each deep identity was added, and keeps the first value added for it.
+//
+// The zero DeepBag is ready to use. A DeepBag is not safe for concurrent use.
+type DeepBag[T any] struct {
+	entries map[DeepHandle[T]]bagEntry[T]
+	len     int
+	// added numbers the entries, to break ties between equal counts.
+	added int
+}
+
+type bagEntry[T any] struct {
+	value T
+	count int
+	order int
+}
+
+func NewDeepBag[T any]() *DeepBag[T] {
+	return &DeepBag[T]{entries: make(map[DeepHandle[T]]bagEntry[T])}
+}
+
+// Add adds one occurrence of value, and returns its new count.
+func (b *DeepBag[T]) Add(value T) (int, error) {
+	handle, err := Make(value)
+	if err != nil {
+		return 0, err
+	}
+	if b.entries == nil {
+		b.entries = make(map[DeepHandle[T]]bagEntry[T])
+	}
+	entry, exists := b.entries[handle]
+	if !exists {
+		entry = bagEntry[T]{value: value, order: b.added}
+		b.added++
+	}
+	entry.count++
+	b.entries[handle] = entry
+	b.len++
+	return entry.count, nil
+}
+
+// Remove removes one occurrence of value, if there is one, and returns its new count.
+func (b *DeepBag[T]) Remove(value T) (int, error) {
+	handle, err := Make(value)
+	if err != nil {
+		return 0, err
+	}
+	entry, exists := b.entries[handle]
+	if !exists {
+		return 0, nil
+	}
+	entry.count--
+	b.len--
+	if entry.count == 0 {
+		delete(b.entries, handle)
+	} else {
+		b.entries[handle] = entry
+	}
+	return entry.count, nil
+}
+
+// Count returns the number of occurrences of values deeply equal to value.
+func (b *DeepBag[T]) Count(value T) (int, error) {
+	handle, err := Make(value)
+	if err != nil {
+		return 0, err
+	}
+	return b.entries[handle].count, nil
+}
+
+// Distinct returns the number of distinct values in the bag.
+func (b *DeepBag[T]) Distinct() int {
+	return len(b.entries)
+}
+
+// Len returns the total number of occurrences in the bag.
+func (b *DeepBag[T]) Len() int {
+	return b.len
+}
+
+// All returns an iterator over the distinct values and their counts, from the
+// most to the least common. Values with the same count are in the order they
+// were first added.
+func (b *DeepBag[T]) All() iter.Seq2[T, int] {
+	return func(yield func(T, int) bool) {
+		entries := slices.SortedFunc(maps.Values(b.entries), func(x, y bagEntry[T]) int {
+			if c := cmp.Compare(y.count, x.count); c != 0 {
+				return c
+			}
+			return cmp.Compare(x.order, y.order)
+		})
+		for _, entry := range entries {
+			if !yield(entry.value, entry.count) {
+				return
+			}
+		}
+	}
+}
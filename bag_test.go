@@ -0,0 +1,87 @@
+// go_api/pkg/deepunique/bag_test.go
+package deepunique
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeepBag(t *testing.T) {
+	type errorPayload struct {
+		Code    int
+		Details []string
+	}
+
+	var bag DeepBag[errorPayload]
+	payloads := []errorPayload{
+		{Code: 500, Details: []string{"db"}},
+		{Code: 404},
+		{Code: 500, Details: []string{"db"}},
+		{Code: 503, Details: []string{"upstream"}},
+		{Code: 404},
+		{Code: 500, Details: []string{"db"}},
+	}
+	for _, payload := range payloads {
+		if _, err := bag.Add(payload); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if bag.Len() != 6 {
+		t.Errorf("expected 6 occurrences, got %v", bag.Len())
+	}
+	if bag.Distinct() != 3 {
+		t.Errorf("expected 3 distinct values, got %v", bag.Distinct())
+	}
+
+	count, err := bag.Count(errorPayload{Code: 500, Details: []string{"db"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3, got %v", count)
+	}
+
+	var codes, counts []int
+	for payload, count := range bag.All() {
+		codes = append(codes, payload.Code)
+		counts = append(counts, count)
+	}
+	if !reflect.DeepEqual(codes, []int{500, 404, 503}) || !reflect.DeepEqual(counts, []int{3, 2, 1}) {
+		t.Errorf("expected codes [500 404 503] with counts [3 2 1], got %v with %v", codes, counts)
+	}
+
+	count, err = bag.Remove(errorPayload{Code: 503, Details: []string{"upstream"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if count != 0 || bag.Distinct() != 2 || bag.Len() != 5 {
+		t.Errorf("expected the payload to be gone, got count %v, %v distinct, %v total", count, bag.Distinct(), bag.Len())
+	}
+
+	count, err = bag.Remove(errorPayload{Code: 503, Details: []string{"upstream"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if count != 0 || bag.Len() != 5 {
+		t.Errorf("expected removing a missing value to do nothing, got count %v, %v total", count, bag.Len())
+	}
+}
+
+func TestDeepBagTies(t *testing.T) {
+	// Shows that values with the same count come out in the order they were first added.
+	bag := NewDeepBag[[]string]()
+	for _, value := range [][]string{{"c"}, {"a"}, {"b"}, {"a"}, {"c"}, {"b"}} {
+		if _, err := bag.Add(value); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	var order []string
+	for value := range bag.All() {
+		order = append(order, value[0])
+	}
+	if !reflect.DeepEqual(order, []string{"c", "a", "b"}) {
+		t.Errorf("expected %v, got %v", []string{"c", "a", "b"}, order)
+	}
+}
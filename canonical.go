@@ -0,0 +1,80 @@
+package deepunique
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// DeepKeyer is implemented by types with their own notion of equality. When a
+// value implements it, the key it returns is canonicalized in place of the
+// value's fields, so two values are equal exactly when their keys are.
+//
+// Map keys are still compared with ==, since that's how maps look them up.
+type DeepKeyer interface {
+	DeepKey() any
+}
+
+var (
+	deepKeyerType = reflect.TypeFor[DeepKeyer]()
+
+	canonicalizers   sync.Map // reflect.Type -> func(reflect.Value) any
+	hasCanonicalizer atomic.Bool
+	deepKeyerTypes   sync.Map // reflect.Type -> bool
+)
+
+// RegisterCanonicalizer makes values of type T canonicalize as the key returned by
+// key, the same as if T implemented DeepKeyer. It is for types you don't own,
+// like time.Time, and takes precedence over a DeepKey method. It only applies to
+// T itself, not to types with T as their underlying type or interfaces T
+// implements, and like DeepKey it doesn't apply to map keys.
+//
+// Handles made before registering won't match handles made after, so register
+// canonicalizers before making any, such as in an init function.
+func RegisterCanonicalizer[T any](key func(T) any) {
+	canonicalizers.Store(reflect.TypeFor[T](), func(value reflect.Value) any {
+		return key(value.Interface().(T))
+	})
+	hasCanonicalizer.Store(true)
+	// Encoders compiled before now don't know about it.
+	encoderFuncs.Clear()
+	plainTypes.Clear()
+}
+
+// canonicalKey returns the key that value canonicalizes as, if its type has one.
+// Values that can't be turned into an interface, like those reached through
+// unexported fields, are canonicalized by their fields.
+// Nil pointers are left alone, since their methods usually can't be called.
+func canonicalKey(value reflect.Value) (reflect.Value, bool) {
+	key, ok := canonicalizer(value.Type())
+	if !ok || !value.CanInterface() || (value.Kind() == reflect.Pointer && value.IsNil()) {
+		return reflect.Value{}, false
+	}
+	return reflect.ValueOf(key(value)), true
+}
+
+func canonicalizer(t reflect.Type) (func(reflect.Value) any, bool) {
+	if hasCanonicalizer.Load() {
+		if key, ok := canonicalizers.Load(t); ok {
+			return key.(func(reflect.Value) any), true
+		}
+	}
+	// Interfaces are canonicalized by their dynamic value instead.
+	if t.Kind() == reflect.Interface || t.NumMethod() == 0 || !implementsDeepKeyer(t) {
+		return nil, false
+	}
+	return deepKey, true
+}
+
+func deepKey(value reflect.Value) any {
+	return value.Interface().(DeepKeyer).DeepKey()
+}
+
+func implementsDeepKeyer(t reflect.Type) bool {
+	if implements, ok := deepKeyerTypes.Load(t); ok {
+		return implements.(bool)
+	}
+	implements := t.Implements(deepKeyerType)
+	deepKeyerTypes.Store(t, implements)
+	return implements
+}
@@ -0,0 +1,218 @@
+// go_api/pkg/deepunique/canonical_test.go
+package deepunique
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+type cachedName struct {
+	Name  string
+	lower *string // derived from Name, filled in lazily
+}
+
+func (c cachedName) DeepKey() any {
+	return c.Name
+}
+
+type normalizedPath struct {
+	Parts []string
+}
+
+// DeepKey returns a normalized copy, which is canonicalized by its fields.
+func (p *normalizedPath) DeepKey() any {
+	parts := make([]string, 0, len(p.Parts))
+	for _, part := range p.Parts {
+		if part != "" {
+			parts = append(parts, strings.ToLower(part))
+		}
+	}
+	return &normalizedPath{Parts: parts}
+}
+
+func TestDeepKeyer(t *testing.T) {
+	lower := "alice"
+	type holder struct {
+		Names []cachedName
+		Path  *normalizedPath
+	}
+
+	tests := []struct {
+		name     string
+		a        any
+		b        any
+		expected bool
+	}{
+		{name: "Cache field ignored", a: cachedName{Name: "Alice", lower: &lower}, b: cachedName{Name: "Alice"}, expected: true},
+		{name: "Different keys", a: cachedName{Name: "Alice"}, b: cachedName{Name: "Bob"}, expected: false},
+		{
+			name:     "Nested keyers",
+			a:        holder{Names: []cachedName{{Name: "Alice", lower: &lower}}, Path: &normalizedPath{Parts: []string{"A", "", "b"}}},
+			b:        holder{Names: []cachedName{{Name: "Alice"}}, Path: &normalizedPath{Parts: []string{"a", "B"}}},
+			expected: true,
+		},
+		{name: "Nil pointer keyer", a: holder{}, b: holder{}, expected: true},
+		{name: "Nil and non-nil pointer keyer", a: holder{}, b: holder{Path: &normalizedPath{}}, expected: false},
+		{name: "Key doesn't equal its type", a: cachedName{Name: "Alice"}, b: "Alice", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handleA, err := Make(tt.a)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			handleB, err := Make(tt.b)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if (handleA == handleB) != tt.expected {
+				t.Errorf("expected equal handles to be %v, got %v", tt.expected, handleA == handleB)
+			}
+			if diffs := Diff(tt.a, tt.b); (len(diffs) == 0) != tt.expected {
+				t.Errorf("expected Diff to agree with Make, got %v", diffs)
+			}
+		})
+	}
+}
+
+func TestDeepKeyerUnexportedField(t *testing.T) {
+	// Shows that DeepKey can't be called through an unexported field, so those
+	// values are canonicalized by their fields instead.
+	lower := "alice"
+	type holder struct {
+		name cachedName
+	}
+
+	handleA, err := Make(holder{name: cachedName{Name: "Alice", lower: &lower}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	handleB, err := Make(holder{name: cachedName{Name: "Alice"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if handleA == handleB {
+		t.Errorf("expected different handles, got %v", handleA)
+	}
+}
+
+func TestRegisterCanonicalizer(t *testing.T) {
+	RegisterCanonicalizer(func(t time.Time) any { return t.UnixNano() })
+	RegisterCanonicalizer(func(b *big.Int) any { return b.String() })
+
+	type event struct {
+		At    time.Time
+		Total *big.Int
+	}
+
+	now := time.Now() // has a monotonic reading
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("no time zone database: %v", err)
+	}
+
+	a := event{At: now, Total: big.NewInt(42)}
+	b := event{At: now.Round(0).In(tokyo), Total: new(big.Int).SetInt64(42)}
+	c := event{At: now.Add(time.Second), Total: big.NewInt(42)}
+
+	handleA, err := Make(a)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	handleB, err := Make(b)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	handleC, err := Make(c)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if handleA != handleB {
+		t.Errorf("expected the same instant in different zones to be equal, got %v", Diff(a, b))
+	}
+	if handleA == handleC {
+		t.Errorf("expected different instants to differ")
+	}
+
+	diffs := Diff(a, c)
+	if len(diffs) != 1 || diffs[0].Path != ".At" {
+		t.Errorf("expected .At to differ, got %v", diffs)
+	}
+
+	fingerprintA, err := Fingerprint(a)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	fingerprintB, err := Fingerprint(b)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if fingerprintA != fingerprintB {
+		t.Errorf("expected equal fingerprints, got %x and %x", fingerprintA, fingerprintB)
+	}
+}
+
+type lowerKey string
+
+func (k lowerKey) DeepKey() any { return strings.ToLower(string(k)) }
+
+type upperKey string
+
+func TestCanonicalMapKeys(t *testing.T) {
+	// Shows that map keys are compared with ==, even when their type canonicalizes
+	// as something else everywhere else.
+	RegisterCanonicalizer(func(k upperKey) any { return strings.ToUpper(string(k)) })
+
+	tests := []struct {
+		name     string
+		a        any
+		b        any
+		expected bool
+	}{
+		{name: "DeepKeyer values", a: []lowerKey{"A"}, b: []lowerKey{"a"}, expected: true},
+		{name: "DeepKeyer keys", a: map[lowerKey]int{"A": 1}, b: map[lowerKey]int{"a": 1}, expected: false},
+		{name: "Canonicalizer values", a: []upperKey{"A"}, b: []upperKey{"a"}, expected: true},
+		{name: "Canonicalizer keys", a: map[upperKey]int{"A": 1}, b: map[upperKey]int{"a": 1}, expected: false},
+		{name: "Keys in an interface", a: map[any]int{lowerKey("A"): 1}, b: map[any]int{lowerKey("a"): 1}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handleA, err := Make(tt.a)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			handleB, err := Make(tt.b)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if (handleA == handleB) != tt.expected {
+				t.Errorf("expected equal handles to be %v, got %v", tt.expected, handleA == handleB)
+			}
+			if diffs := Diff(tt.a, tt.b); (len(diffs) == 0) != tt.expected {
+				t.Errorf("expected no differences to be %v, got %v", tt.expected, diffs)
+			}
+		})
+	}
+
+	// Keys that only differ once canonicalized still encode differently, so the
+	// entries have one order.
+	value := map[lowerKey]int{"A": 1, "a": 2, "b": 3}
+	expected, err := Make(value)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for range 100 {
+		handle, err := Make(map[lowerKey]int{"b": 3, "a": 2, "A": 1})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if handle != expected {
+			t.Fatalf("expected %v, got %v", expected, handle)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package deepunique
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Encoders are compiled once per type, like encoding/json's, so encoding a value
+// only dispatches on its kind, looks up canonicalizers and resolves struct tags
+// the first time its type is seen. Anything that depends on the options or on
+// where the value is, like ignored fields, is still decided while encoding.
+
+// An encoderFunc writes a value of the type it was compiled for.
+type encoderFunc func(e *encoder, value reflect.Value)
+
+var encoderFuncs sync.Map // reflect.Type -> encoderFunc
+
+func typeEncoder(t reflect.Type) encoderFunc {
+	if f, ok := encoderFuncs.Load(t); ok {
+		return f.(encoderFunc)
+	}
+
+	// A recursive type refers to its own encoder while it is being compiled, so
+	// store one that waits for it first.
+	var (
+		wg sync.WaitGroup
+		f  encoderFunc
+	)
+	wg.Add(1)
+	fi, loaded := encoderFuncs.LoadOrStore(t, encoderFunc(func(e *encoder, value reflect.Value) {
+		wg.Wait()
+		f(e, value)
+	}))
+	if loaded {
+		return fi.(encoderFunc)
+	}
+
+	f = newTypeEncoder(t)
+	wg.Done()
+	encoderFuncs.Store(t, f)
+	return f
+}
+
+// newTypeEncoder compiles the encoder for t. A canonical key or Equal method
+// takes the place of the value when there is one, and otherwise it is written by
+// its kind.
+func newTypeEncoder(t reflect.Type) encoderFunc {
+	byKind := newKindEncoder(t)
+	f := byKind
+	if method := lookupEqualMethod(t); method.IsValid() {
+		f = newEqualEncoder(method, f)
+	}
+	if key, ok := canonicalizer(t); ok {
+		f = newCanonicalEncoder(t, key, byKind, f)
+	}
+	return f
+}
+
+// newCanonicalEncoder returns an encoder that writes the key of a value in its
+// place. Values that can't be turned into an interface, like those reached
+// through unexported fields, are written by next instead. So are nil pointers,
+// since their methods usually can't be called.
+func newCanonicalEncoder(t reflect.Type, key func(reflect.Value) any, byKind, next encoderFunc) encoderFunc {
+	return func(e *encoder, value reflect.Value) {
+		if !value.CanInterface() || (value.Kind() == reflect.Pointer && value.IsNil()) {
+			next(e, value)
+			return
+		}
+		k := reflect.ValueOf(key(value))
+		// The key stands in for the value, so its type is written too.
+		if !k.IsValid() || k.Type() != t {
+			e.encodeRoot(k)
+			return
+		}
+		// A key of the same type is a normalized copy of the value, and
+		// canonicalizing it again would never end.
+		e.writeType(t)
+		byKind(e, k)
+	}
+}
+
+func newEqualEncoder(method reflect.Value, next encoderFunc) encoderFunc {
+	return func(e *encoder, value reflect.Value) {
+		if !e.opts.equalMethods || !value.CanInterface() || (value.Kind() == reflect.Pointer && value.IsNil()) {
+			next(e, value)
+			return
+		}
+		// Only the type is known to be the same, Equal decides the rest.
+		e.writeByte(markValue)
+		e.equals = append(e.equals, equalValue{method: method, value: value})
+	}
+}
+
+func newKindEncoder(t reflect.Type) encoderFunc {
+	switch t.Kind() {
+	case reflect.Bool:
+		return encodeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return encodeInt
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return encodeUint
+	case reflect.Float32:
+		return encodeFloat32
+	case reflect.Float64:
+		return encodeFloat64
+	case reflect.Complex64:
+		return encodeComplex64
+	case reflect.Complex128:
+		return encodeComplex128
+	case reflect.String:
+		return encodeString
+	case reflect.Array:
+		return newArrayEncoder(t)
+	case reflect.Struct:
+		return newStructEncoder(t)
+	case reflect.Interface:
+		return encodeInterface
+	case reflect.Pointer:
+		return newPointerEncoder(t)
+	case reflect.Slice:
+		return newSliceEncoder(t)
+	case reflect.Map:
+		return newMapEncoder(t)
+	case reflect.Func:
+		return encodeFunc
+	case reflect.Chan, reflect.UnsafePointer:
+		return encodeIdentity
+	default:
+		// Invalid is handled by encodeRoot, so this is unreachable with the
+		// current reflect version.
+		return encodeInvalid
+	}
+}
+
+func encodeBool(e *encoder, value reflect.Value) {
+	if value.Bool() {
+		e.writeByte(1)
+	} else {
+		e.writeByte(0)
+	}
+}
+
+func encodeInt(e *encoder, value reflect.Value) {
+	e.writeVarint(value.Int())
+}
+
+func encodeUint(e *encoder, value reflect.Value) {
+	e.writeUvarint(value.Uint())
+}
+
+func encodeFloat32(e *encoder, value reflect.Value) {
+	e.writeFloat(value.Float(), 32)
+}
+
+func encodeFloat64(e *encoder, value reflect.Value) {
+	e.writeFloat(value.Float(), 64)
+}
+
+func encodeComplex64(e *encoder, value reflect.Value) {
+	c := value.Complex()
+	e.writeFloat(real(c), 32)
+	e.writeFloat(imag(c), 32)
+}
+
+func encodeComplex128(e *encoder, value reflect.Value) {
+	c := value.Complex()
+	e.writeFloat(real(c), 64)
+	e.writeFloat(imag(c), 64)
+}
+
+func encodeString(e *encoder, value reflect.Value) {
+	e.writeString(value.String())
+}
+
+func encodeInterface(e *encoder, value reflect.Value) {
+	if value.IsNil() {
+		e.writeByte(markNil)
+		return
+	}
+	e.writeByte(markValue)
+	e.encodeRoot(value.Elem())
+}
+
+// encodeFunc writes a non-nil func as unequal to everything, like
+// reflect.DeepEqual. Its code pointer can't stand in for it, since every closure
+// made from one func literal shares it whatever it captured.
+func encodeFunc(e *encoder, value reflect.Value) {
+	if value.IsNil() {
+		e.writeByte(markNil)
+		return
+	}
+	if e.stable {
+		e.fail(value, "it is never equal to anything, so it can't be fingerprinted")
+		return
+	}
+	e.writeByte(markValue)
+	e.writeUnequal()
+}
+
+func encodeIdentity(e *encoder, value reflect.Value) {
+	// Compared by identity, the same as reflect.DeepEqual.
+	if value.IsNil() {
+		e.writeByte(markNil)
+		return
+	}
+	e.writeByte(markValue)
+	e.writeIdentity(value)
+}
+
+func encodeInvalid(e *encoder, value reflect.Value) {
+	e.writeByte(markNil)
+}
+
+func newArrayEncoder(t reflect.Type) encoderFunc {
+	elem := typeEncoder(t.Elem())
+	n := t.Len()
+	return func(e *encoder, value reflect.Value) {
+		if e.unorderedSlice(t) {
+			e.encodeSet(value, elem)
+			return
+		}
+		for i := 0; i < n; i++ {
+			elem(e, value.Index(i))
+			if e.err != nil {
+				e.failedAt(fmt.Sprintf("[%d]", i))
+				return
+			}
+		}
+	}
+}
+
+func newPointerEncoder(t reflect.Type) encoderFunc {
+	elem := typeEncoder(t.Elem())
+	return func(e *encoder, value reflect.Value) {
+		if value.IsNil() {
+			e.writeByte(markNil)
+			return
+		}
+		if !e.enterPath(value) {
+			return
+		}
+		e.writeByte(markValue)
+		elem(e, value.Elem())
+		e.leavePath()
+	}
+}
+
+func newSliceEncoder(t reflect.Type) encoderFunc {
+	elem := typeEncoder(t.Elem())
+	// Fast path for []byte and friends, unless the bytes are compared some other
+	// way than by value.
+	_, canonical := canonicalizer(t.Elem())
+	bytes := t.Elem().Kind() == reflect.Uint8 && !canonical && !lookupEqualMethod(t.Elem()).IsValid()
+	return func(e *encoder, value reflect.Value) {
+		if e.unorderedSlice(t) {
+			e.encodeSet(value, elem)
+			return
+		}
+		// Like reflect.DeepEqual, a nil slice is not equal to an empty one.
+		if value.IsNil() && !e.opts.nilEqualsEmpty {
+			e.writeByte(markNil)
+			return
+		}
+		n := value.Len()
+		if n == 0 {
+			e.writeByte(markValue)
+			e.writeUvarint(0)
+			return
+		}
+		if !e.enterPath(value) {
+			return
+		}
+		e.writeByte(markValue)
+		e.writeUvarint(uint64(n))
+		if bytes {
+			e.buf = append(e.buf, value.Bytes()...)
+		} else {
+			for i := 0; i < n; i++ {
+				elem(e, value.Index(i))
+				if e.err != nil {
+					e.failedAt(fmt.Sprintf("[%d]", i))
+					return
+				}
+			}
+		}
+		e.leavePath()
+	}
+}
+
+func newMapEncoder(t reflect.Type) encoderFunc {
+	elem := typeEncoder(t.Elem())
+	return func(e *encoder, value reflect.Value) {
+		if value.IsNil() {
+			if e.opts.nilEqualsEmpty {
+				e.writeByte(markValue)
+				e.writeUvarint(0)
+			} else {
+				e.writeByte(markNil)
+			}
+			return
+		}
+		if !e.enterPath(value) {
+			return
+		}
+		e.writeByte(markValue)
+		e.encodeMapEntries(value, elem)
+		e.leavePath()
+	}
+}
+
+func newStructEncoder(t reflect.Type) encoderFunc {
+	info := structInfoOf(t)
+	fields := make([]encoderFunc, len(info.fields))
+	for i := range fields {
+		fields[i] = typeEncoder(t.Field(i).Type)
+	}
+
+	return func(e *encoder, value reflect.Value) {
+		if e.fields == nil && !e.opts.ignoreUnexported && !info.tagged {
+			for i, f := range fields {
+				f(e, value.Field(i))
+				if e.err != nil {
+					e.failedAt("." + info.fields[i].name)
+					return
+				}
+			}
+			return
+		}
+
+		// Ignored fields are left out entirely. The type still decides which
+		// fields are there, so the encoding stays unambiguous.
+		parent := e.fields
+		for i := range info.fields {
+			field := &info.fields[i]
+			if field.tag&tagSkip != 0 || (e.opts.ignoreUnexported && !field.exported) {
+				continue
+			}
+			e.fields = parent.child(field.name)
+			if e.fields != nil && e.fields.ignore {
+				continue
+			}
+			unordered := e.unordered
+			if e.fields != nil && e.fields.unordered {
+				e.unordered = true
+			}
+			if field.tag == 0 && field.err == "" {
+				fields[i](e, value.Field(i))
+			} else {
+				e.encodeField(value.Field(i), field)
+			}
+			e.unordered = unordered
+			if e.err != nil {
+				e.failedAt("." + field.name)
+				break
+			}
+		}
+		e.fields = parent
+	}
+}
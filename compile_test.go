@@ -0,0 +1,129 @@
+// go_api/pkg/deepunique/compile_test.go
+package deepunique
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type compileTree struct {
+	Name     string
+	Children []*compileTree
+	Index    map[string]*compileTree
+}
+
+func TestTypeEncoderConcurrent(t *testing.T) {
+	// Shows that a recursive type can be compiled by many goroutines at once.
+	newTree := func() *compileTree {
+		leaf := &compileTree{Name: "leaf"}
+		root := &compileTree{Name: "root", Children: []*compileTree{leaf}, Index: map[string]*compileTree{"leaf": leaf}}
+		leaf.Children = []*compileTree{root}
+		return root
+	}
+
+	expected, err := Make(newTree())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handle, err := Make(newTree())
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+				return
+			}
+			if handle != expected {
+				t.Errorf("expected %v, got %v", expected, handle)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+type compileVersion struct {
+	Major, Minor int
+	Label        string
+}
+
+func TestRegisterCanonicalizerAfterCompiling(t *testing.T) {
+	a := []compileVersion{{Major: 1, Minor: 2, Label: "stable"}}
+	b := []compileVersion{{Major: 1, Minor: 2, Label: "lts"}}
+
+	// Registering can't be undone, so only the first run sees it unregistered.
+	if _, registered := canonicalizer(reflect.TypeFor[compileVersion]()); !registered {
+		handleA, err := Make(a)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		handleB, err := Make(b)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if handleA == handleB {
+			t.Fatalf("expected different handles before registering")
+		}
+	}
+
+	RegisterCanonicalizer(func(v compileVersion) any { return [2]int{v.Major, v.Minor} })
+
+	handleA, err := Make(a)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	handleB, err := Make(b)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if handleA != handleB {
+		t.Errorf("expected equal handles after registering, got %v and %v", handleA, handleB)
+	}
+}
+
+type compileLetter byte
+
+type compileKeyedByte byte
+
+func (b compileKeyedByte) DeepKey() any { return b % 10 }
+
+type compileEqualByte byte
+
+func (b compileEqualByte) Equal(other compileEqualByte) bool { return b%10 == other%10 }
+
+func TestByteSliceElementEquality(t *testing.T) {
+	// Shows that byte slices compare their elements the same way byte arrays do,
+	// rather than by their raw bytes.
+	RegisterCanonicalizer(func(l compileLetter) any { return l | 0x20 })
+
+	tests := []struct {
+		name     string
+		input    []any
+		opts     []Option
+		expected int
+	}{
+		{name: "Canonicalizer on a slice", input: []any{[]compileLetter("Go"), []compileLetter("gO")}, expected: 1},
+		{name: "Canonicalizer on an array", input: []any{[2]compileLetter{'G', 'o'}, [2]compileLetter{'g', 'O'}}, expected: 1},
+		{name: "DeepKeyer on a slice", input: []any{[]compileKeyedByte{1, 2}, []compileKeyedByte{11, 22}}, expected: 1},
+		{name: "DeepKeyer on an array", input: []any{[2]compileKeyedByte{1, 2}, [2]compileKeyedByte{11, 22}}, expected: 1},
+		{name: "Equal method on a slice", input: []any{[]compileEqualByte{1, 2}, []compileEqualByte{11, 22}}, opts: []Option{UseEqualMethods()}, expected: 1},
+		{name: "Equal method on an array", input: []any{[2]compileEqualByte{1, 2}, [2]compileEqualByte{11, 22}}, opts: []Option{UseEqualMethods()}, expected: 1},
+		{name: "Equal method without the option", input: []any{[]compileEqualByte{1, 2}, []compileEqualByte{11, 22}}, expected: 2},
+		{name: "Plain bytes", input: []any{[]byte("Go"), []byte("gO")}, expected: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := UniqueWithOptions(tt.input, tt.opts...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(result) != tt.expected {
+				t.Errorf("expected %v items, got %v", tt.expected, result)
+			}
+		})
+	}
+}
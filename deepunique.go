@@ -1,17 +1,24 @@
 package deepunique
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"unique"
 )
 
+// SerializableHandle is a unique.Handle along with its printed form.
+//
+// Deprecated: it was part of encoding values as JSON, which Make no longer does.
+// Use Fingerprint for a digest that can be stored or shared.
 type SerializableHandle[T comparable] struct {
 	handle unique.Handle[T]
 	Value  string // stringified unique.Handle
 }
 
+// NewSerializableHandle returns the SerializableHandle for value.
+//
+// Deprecated: use Fingerprint.
 func NewSerializableHandle[T comparable](value T) SerializableHandle[T] {
 	handle := unique.Make(value)
 	return SerializableHandle[T]{
@@ -21,128 +28,162 @@ func NewSerializableHandle[T comparable](value T) SerializableHandle[T] {
 	}
 }
 
+// TypedAny is a value along with a handle for its type.
+//
+// Deprecated: it was part of encoding values as JSON, which Make no longer does.
 type TypedAny struct {
 	Type  SerializableHandle[reflect.Type]
 	Value any
 }
 
-// TODO: return a more consistent type
-func deepValueMake(value reflect.Value) any {
-	switch value.Kind() {
-	case reflect.Array:
-		items := make([]any, value.Len())
-		for i := 0; i < value.Len(); i++ {
-			items[i] = deepValueMake(value.Index(i))
-		}
-		return TypedAny{
-			Type:  NewSerializableHandle(value.Type()),
-			Value: items,
-		}
-	case reflect.Slice:
-		items := make([]any, value.Len())
-		for i := 0; i < value.Len(); i++ {
-			items[i] = deepValueMake(value.Index(i))
-		}
-		return TypedAny{
-			Type:  NewSerializableHandle(value.Type()),
-			Value: items,
-		}
-	case reflect.Interface:
-		return TypedAny{
-			Type:  NewSerializableHandle(value.Type()),
-			Value: deepValueMake(value.Elem()),
-		}
-	case reflect.Pointer:
-		return TypedAny{
-			Type:  NewSerializableHandle(value.Type()),
-			Value: deepValueMake(value.Elem()),
-		}
-	case reflect.Struct:
-		items := make([]any, value.NumField())
-		for i, n := 0, value.NumField(); i < n; i++ {
-			items[i] = deepValueMake(value.Field(i))
-		}
-		return TypedAny{
-			Type:  NewSerializableHandle(value.Type()),
-			Value: items,
-		}
-	case reflect.Map:
-		items := make([][2]any, value.Len())
-		index := make([]string, value.Len())
-		iter := value.MapRange()
-		for iter.Next() {
-			// Map keys are comparable, but two different keys can compare equal.
-			key := NewSerializableHandle(iter.Key())
-			val := deepValueMake(iter.Value())
-			items = append(items, [2]any{key, val})
-			index = append(index, key.Value)
-		}
-		SortMapTuples(items, index)
-		return TypedAny{
-			Type:  NewSerializableHandle(value.Type()),
-			Value: items,
-		}
-	case reflect.Func:
-		if value.IsNil() {
-			return TypedAny{
-				Type:  NewSerializableHandle(value.Type()),
-				Value: nil,
-			}
-		}
-		// Slightly different behavior from reflect.DeepEqual:
-		// Performs a pointer comparison instead of always being unique.
-		return NewSerializableHandle(value)
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		// A simple unique.Make(value) fails when value is an Elem of a pointer.
-		// Have to use TypedAny with the cast value.
-		return NewSerializableHandle(value.Interface())
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		return NewSerializableHandle(value.Interface())
-	case reflect.String:
-		return NewSerializableHandle(value.Interface())
-	case reflect.Bool:
-		return NewSerializableHandle(value.Interface())
-	case reflect.Float32, reflect.Float64:
-		return NewSerializableHandle(value.Interface())
-	case reflect.Complex64, reflect.Complex128:
-		return NewSerializableHandle(value.Interface())
-	case reflect.Chan, reflect.UnsafePointer, reflect.Invalid:
-		// Not sure what reflect.DeepEqual is doing here.
-		// This might work.
-		return NewSerializableHandle(value.Interface())
-	default:
-		// unreachable with current reflect version
-		return NewSerializableHandle(value.Interface())
-	}
+// Make returns a handle that is equal for two values exactly when they are deeply
+// equal. The handle keeps everything its encoding depends on reachable, so it
+// can be held for as long as needed.
+//
+// If value is the first with its deep identity, the handle keeps it, without
+// copying, as the representative returned by Value. It must not be modified
+// afterwards, or Value returns the modified value. Use a Pool to keep copies.
+func Make[T any](value T) (DeepHandle[T], error) {
+	return MakeWithOptions(value)
 }
 
-func Make[T any](value T) (unique.Handle[string], any, error) {
-	// TODO: simplify holding the pointers in deep.
-	deep := deepValueMake(reflect.ValueOf(value))
-	// return unique.Make(deep) // Compiles, but panics
-	serialized, err := json.Marshal(deep)
+// MakeWithOptions is like Make, but with the equality semantics changed by opts.
+// Handles made with different options are never equal.
+func MakeWithOptions[T any](value T, opts ...Option) (DeepHandle[T], error) {
+	o := newOptions(opts)
+	if o.equalMethods {
+		return DeepHandle[T]{}, errors.New("deepunique: can't make a handle with UseEqualMethods, since Equal gives no canonical key")
+	}
+	key, pins, _, err := makeKey(value, o)
 	if err != nil {
-		return unique.Handle[string]{}, deep, err
+		return DeepHandle[T]{}, err
+	}
+	return makeHandle(key, o, value, pins), nil
+}
+
+// makeKey returns the interned canonical encoding of value, along with the values
+// that have to stay reachable while it is used and the values left for their
+// Equal methods to compare.
+func makeKey[T any](value T, opts *options) (unique.Handle[string], []reflect.Value, []equalValue, error) {
+	e := getEncoder(opts)
+	defer putEncoder(e)
+	e.encodeRoot(reflect.ValueOf(value))
+	if err := e.error(); err != nil {
+		return unique.Handle[string]{}, nil, nil, err
 	}
-	return unique.Make(string(serialized)), deep, nil
+	// unique.Make copies the string when it keeps it, so the buffer can be reused.
+	return unique.Make(e.bytesString()), e.pins, e.equals, nil
 }
 
 func Unique[T any](items []T) ([]T, error) {
-	seen := make(map[unique.Handle[string]]struct{})
-	deeps := make([]any, 0, len(items))
+	return UniqueWithOptions(items)
+}
+
+// UniqueWithOptions is like Unique, but with the equality semantics changed by opts.
+func UniqueWithOptions[T any](items []T, opts ...Option) ([]T, error) {
+	return uniqueBy(items, func(item T) T { return item }, newOptions(opts))
+}
+
+// UniqueBy is like Unique, but two items are duplicates when the keys extracted
+// from them are deeply equal. The first item for each key is kept.
+func UniqueBy[T, K any](items []T, key func(T) K) ([]T, error) {
+	return uniqueBy(items, key, defaultOptions)
+}
+
+func uniqueBy[T, K any](items []T, key func(T) K, opts *options) ([]T, error) {
+	seen := newSeenKeys[K](opts)
 	result := make([]T, 0, len(items))
 
 	for _, item := range items {
-		handle, deep, err := Make(item)
+		_, first, err := seen.add(key(item))
 		if err != nil {
 			return nil, err
 		}
-		deeps = append(deeps, deep)
-		if _, exists := seen[handle]; !exists {
-			seen[handle] = struct{}{}
+		if first {
 			result = append(result, item)
 		}
 	}
-	_ = deeps // trick to avoid garbage collection. Not sure it works.
 	return result, nil
 }
+
+// seenKeys numbers the canonical encodings seen so far, in first-seen order.
+// The keys are only used while it is, so it holds the pins itself rather than
+// paying for a DeepHandle per item.
+type seenKeys[K any] struct {
+	opts *options
+	keys map[unique.Handle[string]]int
+	// plain numbers values of plain types by themselves, since they can be
+	// compared with == without encoding them. K can't be required to be
+	// comparable, so they are kept as any, but lookups don't keep the key, so
+	// only new values are copied to the heap.
+	plain map[any]int
+	// plainKeys is set if K itself is plain, so values don't have to be checked
+	// one by one. Only an interface's values can be plain or not.
+	plainKeys bool
+	// candidates holds, with UseEqualMethods, the values numbered under each key
+	// that still have to be told apart with their Equal methods.
+	candidates map[unique.Handle[string]][]equalCandidate
+	count      int
+	pins       []reflect.Value
+}
+
+type equalCandidate struct {
+	id     int
+	equals []equalValue
+}
+
+func newSeenKeys[K any](opts *options) *seenKeys[K] {
+	s := &seenKeys[K]{opts: opts}
+	if opts.equalMethods {
+		s.candidates = make(map[unique.Handle[string]][]equalCandidate)
+	} else {
+		s.keys = make(map[unique.Handle[string]]int)
+	}
+	if t := reflect.TypeFor[K](); opts == defaultOptions && (t.Kind() == reflect.Interface || isPlain(t)) {
+		s.plain = make(map[any]int)
+		s.plainKeys = t.Kind() != reflect.Interface
+	}
+	return s
+}
+
+// add returns the number of value's deep identity, and reports whether no value
+// deeply equal to it was added before.
+func (s *seenKeys[K]) add(value K) (int, bool, error) {
+	// Values of different types are never equal, so plain values can be
+	// numbered apart from the rest.
+	if s.plain != nil && (s.plainKeys || s.plainValue(value)) {
+		if i, exists := s.plain[value]; exists {
+			return i, false, nil
+		}
+		s.plain[value] = s.count
+		s.count++
+		return s.count - 1, true, nil
+	}
+	key, pins, equals, err := makeKey(value, s.opts)
+	if err != nil {
+		return 0, false, err
+	}
+	if s.candidates != nil {
+		for _, candidate := range s.candidates[key] {
+			if allEqual(candidate.equals, equals) {
+				return candidate.id, false, nil
+			}
+		}
+		s.candidates[key] = append(s.candidates[key], equalCandidate{id: s.count, equals: equals})
+	} else {
+		if i, exists := s.keys[key]; exists {
+			// A duplicate's pins are the same values as the first one's.
+			return i, false, nil
+		}
+		s.keys[key] = s.count
+	}
+	s.count++
+	s.pins = append(s.pins, pins...)
+	return s.count - 1, true, nil
+}
+
+// plainValue reports whether value, of an interface type, holds a plain value.
+func (s *seenKeys[K]) plainValue(value K) bool {
+	v := any(value)
+	return v != nil && isPlain(reflect.TypeOf(v))
+}
@@ -3,10 +3,13 @@ package deepunique
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"testing"
 	"unique"
+	"unsafe"
 )
 
 func TestComparePointerHandles(t *testing.T) {
@@ -364,23 +367,23 @@ func TestMake(t *testing.T) {
 	bob := "Bob"
 	anotherAlice := "Alice"
 
-	handle1, deep1, err := Make(testStruct{IDs: []int{1, 2}, Name: &alice})
-	//handle1, deep1, err := Make(&alice)
+	handle1, err := Make(testStruct{IDs: []int{1, 2}, Name: &alice})
+	//handle1, err := Make(&alice)
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
-	handle2, deep2, err := Make(testStruct{IDs: []int{1, 2}, Name: &anotherAlice})
-	//handle2, deep2, err := Make(&anotherAlice)
+	handle2, err := Make(testStruct{IDs: []int{1, 2}, Name: &anotherAlice})
+	//handle2, err := Make(&anotherAlice)
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
-	handle3, deep3, err := Make(testStruct{IDs: []int{2}, Name: &bob})
-	//handle3, deep3, err := Make(&bob)
+	handle3, err := Make(testStruct{IDs: []int{2}, Name: &bob})
+	//handle3, err := Make(&bob)
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
-	handle4, deep4, err := Make(testStruct{IDs: []int{2, 1}, Name: &alice})
-	//handle4, deep4, err := Make(&alice)
+	handle4, err := Make(testStruct{IDs: []int{2, 1}, Name: &alice})
+	//handle4, err := Make(&alice)
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
@@ -396,11 +399,6 @@ func TestMake(t *testing.T) {
 	if handle1 == handle4 {
 		t.Errorf("handle1 and handle4 should be different, got %v", handle1)
 	}
-
-	_ = deep1
-	_ = deep2
-	_ = deep3
-	_ = deep4
 }
 
 func TestUnique(t *testing.T) {
@@ -552,25 +550,92 @@ func TestTypeCompare(t *testing.T) {
 }
 
 func TestMapDeepEqual(t *testing.T) {
+	// Shows that map keys are compared with ==, while map values are compared deeply.
 	alice := "Alice"
 	otherAlice := "Alice"
 
+	type keyStruct struct {
+		ID   int
+		Name *string
+	}
+
+	cyclicMap := map[string]any{"a": 1}
+	cyclicMap["self"] = cyclicMap
+	otherCyclicMap := map[string]any{"a": 1}
+	otherCyclicMap["self"] = otherCyclicMap
+
 	tests := []struct {
 		name     string
-		map1     map[*string]int
-		map2     map[*string]int
+		map1     any
+		map2     any
 		expected bool
 	}{
 		{
-			name: "Maps with keys that are pointers to the same value are not deep equal",
-			map1: map[*string]int{
-				&alice: 1,
-			},
-			map2: map[*string]int{
-				&otherAlice: 1,
-			},
+			name:     "Maps with keys that are pointers to the same value are not deep equal",
+			map1:     map[*string]int{&alice: 1},
+			map2:     map[*string]int{&otherAlice: 1},
 			expected: false,
 		},
+		{
+			name:     "Maps with the same pointer keys are deep equal",
+			map1:     map[*string]int{&alice: 1, &otherAlice: 2},
+			map2:     map[*string]int{&otherAlice: 2, &alice: 1},
+			expected: true,
+		},
+		{
+			name:     "Maps with values that are pointers to the same value are deep equal",
+			map1:     map[string]*string{"a": &alice},
+			map2:     map[string]*string{"a": &otherAlice},
+			expected: true,
+		},
+		{
+			name:     "Maps with struct keys holding different pointers are not deep equal",
+			map1:     map[keyStruct]int{{ID: 1, Name: &alice}: 1},
+			map2:     map[keyStruct]int{{ID: 1, Name: &otherAlice}: 1},
+			expected: false,
+		},
+		{
+			name:     "Maps with interface keys of different types are not deep equal",
+			map1:     map[any]int{int32(1): 1},
+			map2:     map[any]int{int64(1): 1},
+			expected: false,
+		},
+		{
+			name:     "Maps with interface keys are deep equal",
+			map1:     map[any]int{1: 1, "a": 2, evilAlice(): 3, nil: 4},
+			map2:     map[any]int{nil: 4, evilAlice(): 3, "a": 2, 1: 1},
+			expected: true,
+		},
+		{
+			name:     "Nested maps are deep equal",
+			map1:     map[string]map[string][]int{"a": {"b": {1, 2}}, "c": nil},
+			map2:     map[string]map[string][]int{"c": nil, "a": {"b": {1, 2}}},
+			expected: true,
+		},
+		{
+			name:     "Nested maps with different values are not deep equal",
+			map1:     map[string]map[string][]int{"a": {"b": {1, 2}}},
+			map2:     map[string]map[string][]int{"a": {"b": {2, 1}}},
+			expected: false,
+		},
+		{
+			name:     "Nested nil and empty maps are not deep equal",
+			map1:     map[string]map[string]int{"a": nil},
+			map2:     map[string]map[string]int{"a": {}},
+			expected: false,
+		},
+		{
+			name:     "Maps with different lengths are not deep equal",
+			map1:     map[string]int{"a": 1},
+			map2:     map[string]int{"a": 1, "b": 2},
+			expected: false,
+		},
+		{
+			name:     "Self-referential maps are deep equal",
+			map1:     cyclicMap,
+			map2:     otherCyclicMap,
+			expected: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -578,6 +643,389 @@ func TestMapDeepEqual(t *testing.T) {
 			if reflect.DeepEqual(tt.map1, tt.map2) != tt.expected {
 				t.Errorf("expected %v, got %v", tt.expected, reflect.DeepEqual(tt.map1, tt.map2))
 			}
+			handle1, err := Make(tt.map1)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			handle2, err := Make(tt.map2)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if (handle1 == handle2) != tt.expected {
+				t.Errorf("expected equal handles to be %v, got %v", tt.expected, handle1 == handle2)
+			}
+		})
+	}
+}
+
+func TestMakeCycles(t *testing.T) {
+	type node struct {
+		Name     string
+		Parent   *node
+		Children []*node
+	}
+
+	newTree := func(name string) *node {
+		root := &node{Name: name}
+		child := &node{Name: "child", Parent: root}
+		root.Children = []*node{child}
+		return root
+	}
+
+	self := &node{Name: "self"}
+	self.Parent = self
+	otherSelf := &node{Name: "self"}
+	otherSelf.Parent = otherSelf
+
+	type cyclicSlice []any
+	slice := cyclicSlice{1, nil}
+	slice[1] = slice
+	otherSlice := cyclicSlice{1, nil}
+	otherSlice[1] = otherSlice
+
+	tests := []struct {
+		name     string
+		a        any
+		b        any
+		expected bool
+	}{
+		{name: "Isomorphic trees with back-pointers", a: newTree("root"), b: newTree("root"), expected: true},
+		{name: "Trees with different names", a: newTree("root"), b: newTree("other"), expected: false},
+		{name: "Self-referential pointers", a: self, b: otherSelf, expected: true},
+		{name: "Self-referential pointer and tree", a: self, b: newTree("self"), expected: false},
+		{name: "Self-referential slices", a: slice, b: otherSlice, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handleA, err := Make(tt.a)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			handleB, err := Make(tt.b)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if (handleA == handleB) != tt.expected {
+				t.Errorf("expected equal handles to be %v, got %v", tt.expected, handleA == handleB)
+			}
+			if reflect.DeepEqual(tt.a, tt.b) != tt.expected {
+				t.Errorf("expected reflect.DeepEqual to be %v", tt.expected)
+			}
+		})
+	}
+}
+
+func TestMakeSharedPointers(t *testing.T) {
+	// Shows that a pointer shared by siblings is not a cycle, matching reflect.DeepEqual.
+	alice := "Alice"
+	otherAlice := "Alice"
+
+	shared := []*string{&alice, &alice}
+	separate := []*string{&alice, &otherAlice}
+
+	handle1, err := Make(shared)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	handle2, err := Make(separate)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if handle1 != handle2 {
+		t.Errorf("expected %v, got %v", handle1, handle2)
+	}
+}
+
+func adder(n int) func(int) int {
+	return func(m int) int { return n + m }
+}
+
+func TestUniqueClosures(t *testing.T) {
+	// Shows that closures of one func literal are told apart, whatever they
+	// captured, since reflect.DeepEqual never takes two non-nil funcs to be equal.
+	add := adder(1)
+	result, err := Unique([]func(int) int{adder(1), adder(2), add, add, nil, nil})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result) != 5 {
+		t.Errorf("expected 5 items, got %v", len(result))
+	}
+
+	result2, err := Unique([]*func(int) int{&add, &add})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result2) != 1 {
+		t.Errorf("expected 1 item, got %v", len(result2))
+	}
+}
+
+func TestMakeMatchesDeepEqual(t *testing.T) {
+	ch := make(chan int)
+	otherCh := make(chan int)
+	one := 1
+	nan := math.NaN()
+	otherNaN := math.NaN()
+	nans := []float64{math.NaN(), math.NaN()}
+	nanKeys := map[float64]int{math.NaN(): 1, math.NaN(): 2}
+	add := adder(1)
+	otherAdd := add
+
+	tests := []struct {
+		name string
+		a    any
+		b    any
+	}{
+		{name: "Nil and empty slices", a: []int(nil), b: []int{}},
+		{name: "Nil and empty maps", a: map[string]int(nil), b: map[string]int{}},
+		{name: "Negative and positive zero", a: math.Copysign(0, -1), b: 0.0},
+		{name: "NaN", a: math.NaN(), b: math.NaN()},
+		{name: "NaN and smallest float32", a: float32(math.NaN()), b: math.Float32frombits(1)},
+		{name: "NaN through the same pointer", a: &nan, b: &nan},
+		{name: "NaN through different pointers", a: &nan, b: &otherNaN},
+		{name: "NaN in the same slice", a: [][]float64{nans}, b: [][]float64{nans}},
+		{name: "NaN in a shorter slice of the same array", a: [][]float64{nans}, b: [][]float64{nans[:1]}},
+		{name: "NaN keys in the same map", a: []any{nanKeys}, b: []any{nanKeys}},
+		{name: "NaN in structs with the same pointer", a: struct{ P *float64 }{&nan}, b: struct{ P *float64 }{&nan}},
+		{name: "Closures of one func literal", a: adder(1), b: adder(2)},
+		{name: "Same closure", a: adder(1), b: adder(1)},
+		{name: "Same func through the same pointer", a: &add, b: &add},
+		{name: "Same func through different pointers", a: &add, b: &otherAdd},
+		{name: "Same value with different types", a: int32(1), b: int64(1)},
+		{name: "Same string with different named types", a: evilAlice(), b: evilAlice2()},
+		{name: "Byte slices", a: []byte("alice"), b: []byte("alice")},
+		{name: "Different byte slices", a: []byte("alice"), b: []byte("bob")},
+		{name: "Interfaces with different dynamic types", a: []any{1, "a"}, b: []any{"a", 1}},
+		{name: "Nil interface elements", a: []any{nil}, b: []any{nil}},
+		{name: "Same channel", a: []chan int{ch}, b: []chan int{ch}},
+		{name: "Different channels", a: ch, b: otherCh},
+		{name: "Nil and non-nil pointers", a: (*int)(nil), b: &one},
+		{name: "Arrays", a: [2]string{"a", "b"}, b: [2]string{"a", "b"}},
+		{name: "Strings that share a prefix", a: [2]string{"ab", "c"}, b: [2]string{"a", "bc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handleA, err := Make(tt.a)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			handleB, err := Make(tt.b)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			expected := reflect.DeepEqual(tt.a, tt.b)
+			if (handleA == handleB) != expected {
+				t.Errorf("expected equal handles to be %v, got %v", expected, handleA == handleB)
+			}
 		})
 	}
 }
+
+type benchRecord struct {
+	ID     int
+	Name   *string
+	Tags   []string
+	Labels map[string]string
+	Score  float64
+}
+
+func benchRecords(n int) []benchRecord {
+	records := make([]benchRecord, n)
+	for i := range records {
+		name := fmt.Sprintf("record-%d", i%(n/2+1))
+		records[i] = benchRecord{
+			ID:     i % (n/2 + 1),
+			Name:   &name,
+			Tags:   []string{"a", "b", name},
+			Labels: map[string]string{"app": "deepunique", "name": name},
+			Score:  float64(i%(n/2+1)) / 3,
+		}
+	}
+	return records
+}
+
+func BenchmarkMake(b *testing.B) {
+	records := benchRecords(1000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := Make(records[i%len(records)])
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnique(b *testing.B) {
+	records := benchRecords(100_000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Unique(records); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUniquePlain(b *testing.B) {
+	type point struct {
+		X, Y int
+		Tag  string
+	}
+	points := make([]point, 100_000)
+	ints := make([]int, 100_000)
+	for i := range points {
+		points[i] = point{X: i % 1000, Y: i % 7, Tag: "p"}
+		ints[i] = i % 1000
+	}
+
+	b.Run("Struct", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Unique(points); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Int", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Unique(ints); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("UniquePointerless", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			UniquePointerless(points)
+		}
+	})
+}
+
+// BenchmarkUniqueSmall compares against SlowUnique, which is quadratic and too
+// slow for BenchmarkUnique's input.
+func BenchmarkUniqueSmall(b *testing.B) {
+	records := benchRecords(1000)
+	b.Run("Unique", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Unique(records); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("SlowUnique", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			SlowUnique(records)
+		}
+	})
+}
+
+func TestUniqueBy(t *testing.T) {
+	type spec struct {
+		Image string
+		Ports []int
+	}
+	type deployment struct {
+		Name   string
+		Spec   spec
+		Status string
+	}
+
+	input := []deployment{
+		{Name: "a", Spec: spec{Image: "nginx", Ports: []int{80}}, Status: "ready"},
+		{Name: "b", Spec: spec{Image: "nginx", Ports: []int{80}}, Status: "pending"},
+		{Name: "c", Spec: spec{Image: "nginx", Ports: []int{80, 443}}, Status: "ready"},
+		{Name: "d", Spec: spec{Image: "redis", Ports: []int{6379}}, Status: "ready"},
+		{Name: "e", Spec: spec{Image: "nginx", Ports: []int{80, 443}}, Status: "failed"},
+	}
+
+	result, err := UniqueBy(input, func(d deployment) spec { return d.Spec })
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := []string{"a", "c", "d"}
+	if len(result) != len(expected) {
+		t.Fatalf("expected length %v, got %v", len(expected), len(result))
+	}
+	for i := range result {
+		if result[i].Name != expected[i] {
+			t.Errorf("expected %v, got %v", expected, result)
+			break
+		}
+	}
+}
+
+func TestMakeDoesNotPanic(t *testing.T) {
+	type inner struct {
+		n int
+	}
+	type hidden struct {
+		b   bool
+		i   int8
+		u   uintptr
+		f   float32
+		c   complex128
+		s   string
+		arr [2]inner
+		sl  []any
+		m   map[string]any
+		ptr *inner
+		ifc any
+		fn  func()
+		ch  chan int
+		up  unsafe.Pointer
+	}
+
+	n := 1
+	full := hidden{
+		b: true, i: -1, u: 2, f: 1.5, c: complex(1, 2), s: "s",
+		arr: [2]inner{{1}, {2}},
+		sl:  []any{nil, 1, inner{3}},
+		m:   map[string]any{"a": nil, "b": []int{1}},
+		ptr: &inner{4},
+		ifc: inner{5},
+		fn:  func() {},
+		ch:  make(chan int),
+		up:  unsafe.Pointer(&n),
+	}
+
+	values := []any{nil, []any{nil}, []any{nil, nil}, map[string]any{"a": nil}, hidden{}, full, &full}
+	for _, value := range values {
+		if _, err := Make(value); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		// A func is never equal to itself unless reached through the same pointer.
+		if diffs := Diff(value, value); (len(diffs) == 0) != reflect.DeepEqual(value, value) {
+			t.Errorf("expected no differences to be %v, got %v", reflect.DeepEqual(value, value), diffs)
+		}
+	}
+
+	handle, err := Make[any](nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if handle.Value() != nil {
+		t.Errorf("expected nil, got %v", handle.Value())
+	}
+
+	result, err := Unique([]any{nil, 1, nil, []any{nil}, []any{nil}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result) != 3 {
+		t.Errorf("expected 3 items, got %v", result)
+	}
+
+	_, err = Fingerprint(full)
+	var unsupported *UnsupportedValueError
+	if !errors.As(err, &unsupported) || unsupported.Path != ".fn" {
+		t.Errorf("expected an UnsupportedValueError at .fn, got %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+package deepunique
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// DiffReason says why two values differ at a path.
+type DiffReason int
+
+const (
+	// TypeMismatch means the values have different types.
+	TypeMismatch DiffReason = iota + 1
+	// LengthMismatch means the slices have different lengths.
+	LengthMismatch
+	// NilVsEmpty means one slice or map is nil and the other is empty.
+	NilVsEmpty
+	// NilMismatch means one value is nil and the other isn't.
+	NilMismatch
+	// MissingKey means a map key is only on one side.
+	MissingKey
+	// ValueMismatch means the values themselves differ.
+	ValueMismatch
+)
+
+func (r DiffReason) String() string {
+	switch r {
+	case TypeMismatch:
+		return "type mismatch"
+	case LengthMismatch:
+		return "length mismatch"
+	case NilVsEmpty:
+		return "nil vs empty"
+	case NilMismatch:
+		return "nil vs non-nil"
+	case MissingKey:
+		return "missing key"
+	case ValueMismatch:
+		return "value mismatch"
+	default:
+		return fmt.Sprintf("DiffReason(%d)", int(r))
+	}
+}
+
+// Difference is one place where two values differ.
+type Difference struct {
+	// Path leads from the values passed to Diff to the difference, such as
+	// `.Spec.Ports[2].Labels["app"]`. Pointers and interfaces don't add to it.
+	Path string
+	// A and B are the values at Path, or nil if there is none. Values that can't
+	// be turned back into an interface, like unexported fields, are given as a
+	// reflect.Value.
+	A, B   any
+	Reason DiffReason
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: %v (%v vs %v)", d.Path, d.Reason, d.A, d.B)
+}
+
+// Diff returns where a and b differ, or nil if Make would give them the same
+// handle. It compares values with the package's own rules, not those of
+// reflect.DeepEqual.
+func Diff(a, b any) []Difference {
+	d := differ{visited: make(map[diffVisit]int), deferTo: math.MaxInt}
+	d.diff("", reflect.ValueOf(a), reflect.ValueOf(b))
+	return d.diffs
+}
+
+type diffVisit struct {
+	a, b visit
+}
+
+type differ struct {
+	diffs []Difference
+	// visited maps the pairs being compared on the current path to the depth
+	// they were entered at, so cycles end.
+	visited map[diffVisit]int
+	depth   int
+	// deferTo is the shallowest depth that a cycle below has referred back to.
+	deferTo int
+}
+
+func diffValue(value reflect.Value) any {
+	if !value.IsValid() {
+		return nil
+	}
+	if value.CanInterface() {
+		return value.Interface()
+	}
+	return value
+}
+
+func (d *differ) add(path string, a, b reflect.Value, reason DiffReason) {
+	d.diffs = append(d.diffs, Difference{Path: path, A: diffValue(a), B: diffValue(b), Reason: reason})
+}
+
+// encodeValue returns the canonical encoding of value, leaving out its type.
+func encodeValue(value reflect.Value) []byte {
+	e := getEncoder(defaultOptions)
+	defer putEncoder(e)
+	e.encode(value)
+	return bytes.Clone(e.buf)
+}
+
+func (d *differ) diff(path string, a, b reflect.Value) {
+	if !a.IsValid() || !b.IsValid() {
+		if a.IsValid() != b.IsValid() {
+			d.add(path, a, b, NilMismatch)
+		}
+		return
+	}
+	if a.Type() != b.Type() {
+		d.add(path, a, b, TypeMismatch)
+		return
+	}
+	// The encodings decide equality, so nothing below here can be reported
+	// unless they differ.
+	if bytes.Equal(encodeValue(a), encodeValue(b)) {
+		return
+	}
+
+	// A canonical key replaces the fields, so they don't explain anything.
+	if _, ok := canonicalKey(a); ok {
+		d.add(path, a, b, ValueMismatch)
+		return
+	}
+
+	depth := d.depth
+	va, _, okA := enterCycle(a, nil)
+	vb, _, okB := enterCycle(b, nil)
+	if okA && okB {
+		pair := diffVisit{va, vb}
+		if entered, exists := d.visited[pair]; exists {
+			// Already being compared further up, so the difference is decided there.
+			d.deferTo = min(d.deferTo, entered)
+			return
+		}
+		d.visited[pair] = depth
+		d.depth++
+		defer func() {
+			delete(d.visited, pair)
+			d.depth--
+		}()
+	}
+
+	before := len(d.diffs)
+	outerDeferTo := d.deferTo
+	d.deferTo = math.MaxInt
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		d.diffReference(path, a, b)
+	case reflect.Array:
+		for i := 0; i < a.Len(); i++ {
+			d.diff(fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i))
+		}
+	case reflect.Struct:
+		for i, field := range structInfoOf(a.Type()).fields {
+			switch {
+			case field.tag&tagSkip != 0:
+			case field.tag != 0:
+				d.diffTagged(path+"."+field.name, a.Field(i), b.Field(i), &field)
+			default:
+				d.diff(path+"."+field.name, a.Field(i), b.Field(i))
+			}
+		}
+	default:
+		d.add(path, a, b, ValueMismatch)
+	}
+
+	// The parts can look equal while the whole doesn't, like a cycle against its
+	// unrolling. Unless a cycle leaves that for a node further up to decide,
+	// report the whole so a difference is never lost.
+	if d.deferTo < depth {
+		d.deferTo = min(outerDeferTo, d.deferTo)
+		return
+	}
+	d.deferTo = outerDeferTo
+	if len(d.diffs) == before {
+		d.add(path, a, b, ValueMismatch)
+	}
+}
+
+// diffTagged compares struct fields with a tag. The tag changes what equal means
+// for everything below the field, so a difference is reported at the field.
+func (d *differ) diffTagged(path string, a, b reflect.Value, field *structField) {
+	e := getEncoder(defaultOptions)
+	defer putEncoder(e)
+	e.encodeField(a, field)
+	encodingA := bytes.Clone(e.buf)
+	e.buf = e.buf[:0]
+	e.encodeField(b, field)
+	if bytes.Equal(encodingA, e.buf) {
+		return
+	}
+	reason := ValueMismatch
+	if field.tag&tagPtr != 0 && a.IsNil() != b.IsNil() {
+		reason = NilMismatch
+	}
+	d.add(path, a, b, reason)
+}
+
+// diffReference compares values that can be nil.
+func (d *differ) diffReference(path string, a, b reflect.Value) {
+	if a.IsNil() || b.IsNil() {
+		reason := NilMismatch
+		if a.Kind() == reflect.Slice || a.Kind() == reflect.Map {
+			if a.Len() == 0 && b.Len() == 0 {
+				reason = NilVsEmpty
+			}
+		}
+		d.add(path, a, b, reason)
+		return
+	}
+
+	switch a.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		d.diff(path, a.Elem(), b.Elem())
+	case reflect.Slice:
+		if a.Len() != b.Len() {
+			d.add(path, a, b, LengthMismatch)
+			return
+		}
+		for i := 0; i < a.Len(); i++ {
+			d.diff(fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i))
+		}
+	case reflect.Map:
+		d.diffMap(path, a, b)
+	}
+}
+
+func (d *differ) diffMap(path string, a, b reflect.Value) {
+	// Keys are matched the same way the encoding matches them, with ==.
+	keysA := mapKeysByEncoding(a)
+	keysB := mapKeysByEncoding(b)
+	encodings := make([]string, 0, len(keysA)+len(keysB))
+	for encoding := range keysA {
+		encodings = append(encodings, encoding)
+	}
+	for encoding := range keysB {
+		if _, exists := keysA[encoding]; !exists {
+			encodings = append(encodings, encoding)
+		}
+	}
+	slices.Sort(encodings)
+
+	for _, encoding := range encodings {
+		keyA, inA := keysA[encoding]
+		keyB, inB := keysB[encoding]
+		switch {
+		case inA && inB:
+			d.diff(path+formatMapKey(keyA), a.MapIndex(keyA), b.MapIndex(keyB))
+		case inA:
+			d.add(path+formatMapKey(keyA), a.MapIndex(keyA), reflect.Value{}, MissingKey)
+		default:
+			d.add(path+formatMapKey(keyB), reflect.Value{}, b.MapIndex(keyB), MissingKey)
+		}
+	}
+}
+
+func mapKeysByEncoding(value reflect.Value) map[string]reflect.Value {
+	keys := make(map[string]reflect.Value, value.Len())
+	e := getEncoder(defaultOptions)
+	defer putEncoder(e)
+	for _, key := range value.MapKeys() {
+		e.buf = e.buf[:0]
+		e.encodeKey(key)
+		keys[string(e.buf)] = key
+	}
+	return keys
+}
+
+func formatMapKey(key reflect.Value) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	if key.Kind() == reflect.String {
+		fmt.Fprintf(&b, "%q", key.String())
+	} else {
+		fmt.Fprintf(&b, "%v", diffValue(key))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
@@ -0,0 +1,178 @@
+// go_api/pkg/deepunique/diff_test.go
+package deepunique
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type port struct {
+		Number int
+		Labels map[string]string
+	}
+	type spec struct {
+		Ports []port
+		Tags  []string
+		Owner *string
+	}
+	type resource struct {
+		Name  string
+		Spec  spec
+		Extra any
+	}
+
+	alice := "Alice"
+	otherAlice := "Alice"
+	bob := "Bob"
+
+	newResource := func() resource {
+		return resource{
+			Name: "web",
+			Spec: spec{
+				Ports: []port{
+					{Number: 80, Labels: map[string]string{"app": "web"}},
+					{Number: 443, Labels: map[string]string{"app": "web"}},
+				},
+				Owner: &alice,
+			},
+			Extra: 1,
+		}
+	}
+
+	type expectedDiff struct {
+		path   string
+		reason DiffReason
+	}
+
+	tests := []struct {
+		name     string
+		change   func(r *resource)
+		expected []expectedDiff
+	}{
+		{name: "Equal", change: func(r *resource) {}},
+		{name: "Pointers to equal values", change: func(r *resource) { r.Spec.Owner = &otherAlice }},
+		{
+			name:     "Map value",
+			change:   func(r *resource) { r.Spec.Ports[1].Labels["app"] = "api" },
+			expected: []expectedDiff{{path: `.Spec.Ports[1].Labels["app"]`, reason: ValueMismatch}},
+		},
+		{
+			name:     "Missing map key",
+			change:   func(r *resource) { r.Spec.Ports[0].Labels["tier"] = "front" },
+			expected: []expectedDiff{{path: `.Spec.Ports[0].Labels["tier"]`, reason: MissingKey}},
+		},
+		{
+			name:     "Slice length",
+			change:   func(r *resource) { r.Spec.Ports = r.Spec.Ports[:1] },
+			expected: []expectedDiff{{path: ".Spec.Ports", reason: LengthMismatch}},
+		},
+		{
+			name:     "Nil vs empty",
+			change:   func(r *resource) { r.Spec.Tags = []string{}; r.Spec.Ports[0].Labels = nil },
+			expected: []expectedDiff{{path: ".Spec.Ports[0].Labels", reason: NilMismatch}, {path: ".Spec.Tags", reason: NilVsEmpty}},
+		},
+		{
+			name:     "Nil pointer",
+			change:   func(r *resource) { r.Spec.Owner = nil },
+			expected: []expectedDiff{{path: ".Spec.Owner", reason: NilMismatch}},
+		},
+		{
+			name:     "Pointed-to value",
+			change:   func(r *resource) { r.Name = "api"; r.Spec.Owner = &bob },
+			expected: []expectedDiff{{path: ".Name", reason: ValueMismatch}, {path: ".Spec.Owner", reason: ValueMismatch}},
+		},
+		{
+			name:     "Interface types",
+			change:   func(r *resource) { r.Extra = int64(1) },
+			expected: []expectedDiff{{path: ".Extra", reason: TypeMismatch}},
+		},
+		{
+			name:     "NaN",
+			change:   func(r *resource) { r.Extra = math.NaN() },
+			expected: []expectedDiff{{path: ".Extra", reason: TypeMismatch}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newResource()
+			b := newResource()
+			tt.change(&b)
+
+			diffs := Diff(a, b)
+			if len(diffs) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, diffs)
+			}
+			for i, diff := range diffs {
+				if diff.Path != tt.expected[i].path || diff.Reason != tt.expected[i].reason {
+					t.Errorf("expected %v, got %v", tt.expected[i], diff)
+				}
+			}
+
+			handleA, err := Make(a)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			handleB, err := Make(b)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if (handleA == handleB) != (len(diffs) == 0) {
+				t.Errorf("expected Diff to agree with Make, got %v differences and equal handles %v", len(diffs), handleA == handleB)
+			}
+		})
+	}
+}
+
+func TestDiffValues(t *testing.T) {
+	diffs := Diff(map[string][]int{"a": {1, 2}}, map[string][]int{"a": {1, 3}})
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 difference, got %v", diffs)
+	}
+	if diffs[0].Path != `["a"][1]` || diffs[0].A != 2 || diffs[0].B != 3 {
+		t.Errorf(`expected ["a"][1] to differ with 2 vs 3, got %v`, diffs[0])
+	}
+
+	if diffs := Diff(math.NaN(), math.NaN()); len(diffs) != 1 || diffs[0].Reason != ValueMismatch {
+		t.Errorf("expected NaN to differ from NaN, got %v", diffs)
+	}
+	if diffs := Diff(nil, 1); len(diffs) != 1 || diffs[0].Reason != NilMismatch {
+		t.Errorf("expected nil to differ from 1, got %v", diffs)
+	}
+	if diffs := Diff(evilAlice(), evilAlice2()); len(diffs) != 1 || diffs[0].Reason != TypeMismatch {
+		t.Errorf("expected different named types to differ, got %v", diffs)
+	}
+}
+
+func TestDiffCycles(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+
+	newRing := func(names ...string) *node {
+		nodes := make([]*node, len(names))
+		for i, name := range names {
+			nodes[i] = &node{Name: name}
+		}
+		for i := range nodes {
+			nodes[i].Next = nodes[(i+1)%len(nodes)]
+		}
+		return nodes[0]
+	}
+
+	if diffs := Diff(newRing("a", "b"), newRing("a", "b")); len(diffs) != 0 {
+		t.Errorf("expected no differences, got %v", diffs)
+	}
+
+	diffs := Diff(newRing("a", "b", "c"), newRing("a", "x", "c"))
+	if len(diffs) != 1 || diffs[0].Path != ".Next.Name" {
+		t.Errorf("expected .Next.Name to differ, got %v", diffs)
+	}
+
+	// A ring is not equal to its unrolling, even though every part looks equal.
+	if diffs := Diff(newRing("a"), newRing("a", "a")); len(diffs) == 0 {
+		t.Errorf("expected a difference between a ring and its unrolling")
+	}
+}
@@ -0,0 +1,422 @@
+package deepunique
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// The canonical encoding is written straight from the reflect.Value, so there
+// is no intermediate tree to allocate or marshal.
+//
+// Types are written as small integer ids, and only where the static type
+// doesn't already decide them: at the root and behind interfaces. Every other
+// value is self-delimiting given its type, so two values of the same type
+// encode to the same bytes exactly when they are deep equal.
+
+// Markers for values that can be nil or refer back up the path.
+const (
+	markNil byte = iota
+	markValue
+	markCycle
+)
+
+var (
+	typeIDs    sync.Map // reflect.Type -> uint64
+	nextTypeID atomic.Uint64
+	// nextUnequal numbers values that aren't equal to anything, like NaN.
+	nextUnequal atomic.Uint64
+)
+
+// typeID returns a process-wide id for t. Types are never freed, so ids are
+// stable for the life of the process. 0 is reserved for the nil interface.
+func typeID(t reflect.Type) uint64 {
+	if id, ok := typeIDs.Load(t); ok {
+		return id.(uint64)
+	}
+	id, _ := typeIDs.LoadOrStore(t, nextTypeID.Add(1))
+	return id.(uint64)
+}
+
+// visit identifies a node that can take part in a cycle, the same way
+// reflect.DeepEqual tracks visited pointers. Slices also record their length
+// since a shorter subslice of the same array is a different value.
+type visit struct {
+	ptr unsafe.Pointer
+	len int
+	typ reflect.Type
+}
+
+// enterCycle returns the visit for value if it can form a cycle, and how many
+// nodes up the path it already appears (0 if it doesn't).
+func enterCycle(value reflect.Value, path []visit) (visit, int, bool) {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Map:
+		if value.IsNil() {
+			return visit{}, 0, false
+		}
+	case reflect.Slice:
+		if value.Len() == 0 {
+			return visit{}, 0, false
+		}
+	default:
+		return visit{}, 0, false
+	}
+	v := visit{ptr: value.UnsafePointer(), typ: value.Type()}
+	if value.Kind() == reflect.Slice {
+		v.len = value.Len()
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] == v {
+			return v, len(path) - i, true
+		}
+	}
+	return v, 0, true
+}
+
+type encoder struct {
+	opts *options
+	// fields is the ignored field paths below the value being encoded.
+	fields *fieldNode
+	// stable writes an encoding that only depends on types and values, for
+	// fingerprints. Anything compared by address is an error.
+	stable bool
+	// fold is set while encoding a field tagged ci.
+	fold bool
+	// unordered is set while slices are compared as multisets.
+	unordered bool
+	// err is the first value that couldn't be encoded. Encoding stops there, and
+	// the path to it is filled in on the way back up.
+	err *UnsupportedValueError
+
+	buf []byte
+	// scratch is reused to hold map entries while they are sorted.
+	scratch []byte
+	path    []visit
+	// pins hold values whose identity, not content, is in the encoding.
+	// They have to stay reachable while the encoding is in use.
+	pins []reflect.Value
+	// equals hold the values left for their Equal methods to compare, in the
+	// order they were reached.
+	equals []equalValue
+}
+
+var encoderPool = sync.Pool{
+	New: func() any { return new(encoder) },
+}
+
+func getEncoder(opts *options) *encoder {
+	e := encoderPool.Get().(*encoder)
+	e.opts = opts
+	e.fields = opts.fields
+	e.unordered = opts.unorderedSlices
+	return e
+}
+
+func putEncoder(e *encoder) {
+	e.reset()
+	e.opts = nil
+	e.fields = nil
+	e.stable = false
+	e.unordered = false
+	encoderPool.Put(e)
+}
+
+// reset readies e to encode another value with the same options. The last
+// value's pins and equals may have been handed out, so they aren't reused.
+func (e *encoder) reset() {
+	e.fields = e.opts.fields
+	e.fold = false
+	e.unordered = e.opts.unorderedSlices
+	e.err = nil
+	e.buf = e.buf[:0]
+	e.path = e.path[:0]
+	e.pins = nil
+	e.equals = nil
+}
+
+func (e *encoder) writeByte(b byte) {
+	e.buf = append(e.buf, b)
+}
+
+func (e *encoder) writeUvarint(u uint64) {
+	e.buf = binary.AppendUvarint(e.buf, u)
+}
+
+func (e *encoder) writeVarint(i int64) {
+	e.buf = binary.AppendVarint(e.buf, i)
+}
+
+func (e *encoder) writeString(s string) {
+	if e.fold {
+		s = foldString(s)
+	}
+	e.writeUvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// writeFloat writes f so that the bytes are equal exactly when the floats are ==.
+// -0 is written as 0, and NaNs are written with writeUnequal since NaN != NaN,
+// unless NaNEqualsNaN is set and they all get id 0.
+func (e *encoder) writeFloat(f float64, bits int) {
+	if f == 0 {
+		f = 0
+	}
+	if math.IsNaN(f) {
+		if bits == 32 {
+			e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(float32(math.NaN())))
+		} else {
+			e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(math.NaN()))
+		}
+		if e.opts.nanEqualsNaN || e.stable {
+			e.writeUvarint(0)
+		} else {
+			e.writeUnequal()
+		}
+		return
+	}
+	if bits == 32 {
+		e.buf = binary.LittleEndian.AppendUint32(e.buf, math.Float32bits(float32(f)))
+		return
+	}
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(f))
+}
+
+// writeUnequal writes an id for a value that isn't equal to anything, not even
+// itself. reflect.DeepEqual still takes a pointer, map or slice to be equal to
+// itself without looking inside, so below one the id is that of the innermost,
+// and the value only matches itself reached through it again. Elsewhere it gets
+// a fresh id.
+func (e *encoder) writeUnequal() {
+	if len(e.path) == 0 {
+		e.writeByte(1)
+		e.writeUvarint(nextUnequal.Add(1))
+		return
+	}
+	node := e.path[len(e.path)-1]
+	// The address is in the encoding, so it can't be reused while that's in use.
+	e.pins = append(e.pins, reflect.ValueOf(node.ptr))
+	e.writeByte(2)
+	e.writeUvarint(uint64(uintptr(node.ptr)))
+	e.writeUvarint(uint64(node.len))
+	e.writeUvarint(typeID(node.typ))
+}
+
+func (e *encoder) writeIdentity(value reflect.Value) {
+	if e.stable {
+		e.fail(value, "it is compared by address, so it can't be fingerprinted")
+		return
+	}
+	e.pins = append(e.pins, value)
+	e.writeUvarint(uint64(value.Pointer()))
+}
+
+// UnsupportedValueError is returned for a value that can't be encoded.
+type UnsupportedValueError struct {
+	// Path leads from the value passed in to the unsupported one, in the same
+	// form as Difference.Path.
+	Path   string
+	Type   reflect.Type
+	Reason string
+
+	// segments is Path backwards, while it's being filled in.
+	segments []string
+}
+
+func (err *UnsupportedValueError) Error() string {
+	if err.Path == "" {
+		return fmt.Sprintf("deepunique: unsupported %v: %s", err.Type, err.Reason)
+	}
+	return fmt.Sprintf("deepunique: unsupported %v at %s: %s", err.Type, err.Path, err.Reason)
+}
+
+func (e *encoder) fail(value reflect.Value, reason string) {
+	if e.err == nil {
+		e.err = &UnsupportedValueError{Type: value.Type(), Reason: reason}
+	}
+}
+
+// failedAt adds segment to the path of the error, on its way back up from where
+// encoding failed.
+func (e *encoder) failedAt(segment string) {
+	e.err.segments = append(e.err.segments, segment)
+}
+
+// error returns the error encoding failed with, if it did.
+func (e *encoder) error() error {
+	if e.err == nil {
+		return nil
+	}
+	slices.Reverse(e.err.segments)
+	e.err.Path = strings.Join(e.err.segments, "")
+	e.err.segments = nil
+	return e.err
+}
+
+func (e *encoder) writeType(t reflect.Type) {
+	if e.stable {
+		digest := stableTypeDigest(t)
+		e.buf = append(e.buf, digest[:]...)
+		return
+	}
+	e.writeUvarint(typeID(t))
+}
+
+// encodeRoot writes the type of value followed by the value itself.
+func (e *encoder) encodeRoot(value reflect.Value) {
+	if !value.IsValid() {
+		if e.stable {
+			var none [sha256.Size]byte
+			e.buf = append(e.buf, none[:]...)
+			return
+		}
+		e.writeUvarint(0)
+		return
+	}
+	e.writeType(value.Type())
+	e.encode(value)
+}
+
+// enterPath writes the cycle reference and returns false if value is already
+// being encoded further up the path. Otherwise value is pushed onto the path,
+// and the caller must pop it when done. value must be a non-nil pointer or map,
+// or a non-empty slice.
+func (e *encoder) enterPath(value reflect.Value) bool {
+	v, depth, _ := enterCycle(value, e.path)
+	if depth > 0 {
+		e.writeByte(markCycle)
+		e.writeUvarint(uint64(depth))
+		return false
+	}
+	e.path = append(e.path, v)
+	return true
+}
+
+func (e *encoder) leavePath() {
+	e.path = e.path[:len(e.path)-1]
+}
+
+// encode writes value with the encoder compiled for its type.
+func (e *encoder) encode(value reflect.Value) {
+	typeEncoder(value.Type())(e, value)
+}
+
+// unorderedSlice reports whether slices or arrays of type t are compared as
+// multisets where they are being encoded.
+func (e *encoder) unorderedSlice(t reflect.Type) bool {
+	if e.opts.unorderedTypes[t] {
+		return true
+	}
+	// Byte slices are usually data rather than collections.
+	return e.unordered && t.Elem().Kind() != reflect.Uint8
+}
+
+// encodeMapEntries writes the map's entries sorted by the encoding of their keys,
+// then of their values.
+//
+// reflect.DeepEqual looks keys up with ==, not deep equality, so keys are
+// written with encodeKey while values are written deeply.
+//
+// The entries are written to the end of the buffer as they come, and then moved
+// into order, so nothing is allocated per entry.
+func (e *encoder) encodeMapEntries(value reflect.Value, elem encoderFunc) {
+	type entry struct {
+		key, value, end int
+		// equals is where the entry's values left for Equal methods are.
+		equals, equalsEnd int
+	}
+	start := len(e.buf)
+	equalsStart := len(e.equals)
+	entries := make([]entry, 0, value.Len())
+	iter := value.MapRange()
+	for iter.Next() {
+		ent := entry{key: len(e.buf), equals: len(e.equals)}
+		e.encodeKey(iter.Key())
+		ent.value = len(e.buf)
+		elem(e, iter.Value())
+		if e.err != nil {
+			e.failedAt(formatMapKey(iter.Key()))
+			return
+		}
+		ent.end, ent.equalsEnd = len(e.buf), len(e.equals)
+		entries = append(entries, ent)
+	}
+	slices.SortFunc(entries, func(a, b entry) int {
+		if c := bytes.Compare(e.buf[a.key:a.value], e.buf[b.key:b.value]); c != 0 {
+			return c
+		}
+		// Keys only tie when they are NaNs that are written as equal, and then
+		// their values decide the order.
+		return bytes.Compare(e.buf[a.value:a.end], e.buf[b.value:b.end])
+	})
+
+	written := append(e.scratch[:0], e.buf[start:]...)
+	e.buf = e.buf[:start]
+	e.writeUvarint(uint64(len(entries)))
+	for _, ent := range entries {
+		e.buf = append(e.buf, written[ent.key-start:ent.end-start]...)
+	}
+	e.scratch = written[:0]
+
+	if len(e.equals) > equalsStart {
+		equals := slices.Clone(e.equals[equalsStart:])
+		e.equals = e.equals[:equalsStart]
+		for _, ent := range entries {
+			e.equals = append(e.equals, equals[ent.equals-equalsStart:ent.equalsEnd-equalsStart]...)
+		}
+	}
+}
+
+// encodeKey writes a map key so that the bytes are equal exactly when the keys
+// are ==. Pointers are written by address rather than followed.
+func (e *encoder) encodeKey(value reflect.Value) {
+	// A ci tag doesn't reach keys, or keys that differ only by case would
+	// encode the same and the order of the entries would be left to chance.
+	fold := e.fold
+	e.fold = false
+	defer func() { e.fold = fold }()
+
+	switch value.Kind() {
+	case reflect.Array:
+		for i, n := 0, value.Len(); i < n; i++ {
+			e.encodeKey(value.Index(i))
+		}
+	case reflect.Struct:
+		for i, n := 0, value.NumField(); i < n; i++ {
+			e.encodeKey(value.Field(i))
+		}
+	case reflect.Interface:
+		if value.IsNil() {
+			e.writeByte(markNil)
+			return
+		}
+		e.writeByte(markValue)
+		e.writeType(value.Elem().Type())
+		e.encodeKey(value.Elem())
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		if value.IsNil() {
+			e.writeByte(markNil)
+			return
+		}
+		e.writeByte(markValue)
+		e.writeIdentity(value)
+	default:
+		// Everything else that can be a key is a leaf, written by its kind
+		// alone, since canonicalizers and Equal methods don't apply to keys.
+		newKindEncoder(value.Type())(e, value)
+	}
+}
+
+// bytesString returns the encoding without copying it. It is only safe to pass
+// to functions that copy it if they keep it, like unique.Make.
+func (e *encoder) bytesString() string {
+	return unsafe.String(unsafe.SliceData(e.buf), len(e.buf))
+}
@@ -0,0 +1,51 @@
+package deepunique
+
+import (
+	"reflect"
+	"sync"
+)
+
+var (
+	boolType     = reflect.TypeFor[bool]()
+	equalMethods sync.Map // reflect.Type -> reflect.Value, the zero Value if there is none
+)
+
+// lookupEqualMethod returns the func (T) Equal(T) bool method of t, or the zero
+// Value if it has none.
+func lookupEqualMethod(t reflect.Type) reflect.Value {
+	if method, ok := equalMethods.Load(t); ok {
+		return method.(reflect.Value)
+	}
+	var method reflect.Value
+	// Interfaces are compared by their dynamic value instead.
+	if m, ok := t.MethodByName("Equal"); ok && t.Kind() != reflect.Interface {
+		mt := m.Type
+		if mt.NumIn() == 2 && mt.In(1) == t && mt.NumOut() == 1 && mt.Out(0) == boolType {
+			method = m.Func
+		}
+	}
+	equalMethods.Store(t, method)
+	return method
+}
+
+// equalValue is a value left out of an encoding, to be compared with its Equal
+// method instead.
+type equalValue struct {
+	method reflect.Value
+	value  reflect.Value
+}
+
+// allEqual reports whether each of a is Equal to the value at the same place in
+// b. Values with the same encoding have their equalValues at the same places, so
+// they line up.
+func allEqual(a, b []equalValue) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].method.Call([]reflect.Value{a[i].value, b[i].value})[0].Bool() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,169 @@
+// go_api/pkg/deepunique/equal_test.go
+package deepunique
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// stamp is a time in seconds with a zone that Equal ignores, like time.Time.
+type stamp struct {
+	unix int64
+	zone string
+}
+
+func (s stamp) Equal(other stamp) bool {
+	return s.unix == other.unix
+}
+
+type label struct {
+	Text string
+}
+
+func (l *label) Equal(other *label) bool {
+	return other != nil && strings.EqualFold(l.Text, other.Text)
+}
+
+// almost has an Equal method of the wrong form, so it's compared by its fields.
+type almost struct {
+	N int
+}
+
+func (a almost) Equal(other any) bool {
+	return true
+}
+
+func TestUseEqualMethods(t *testing.T) {
+	type event struct {
+		Name  string
+		At    stamp
+		Label *label
+	}
+
+	tests := []struct {
+		name     string
+		input    []any
+		opts     []Option
+		expected []any
+	}{
+		{
+			name:     "Zones ignored",
+			input:    []any{stamp{1, "UTC"}, stamp{1, "Asia/Tokyo"}, stamp{2, "UTC"}},
+			opts:     []Option{UseEqualMethods()},
+			expected: []any{stamp{1, "UTC"}, stamp{2, "UTC"}},
+		},
+		{
+			name:     "Without the option",
+			input:    []any{stamp{1, "UTC"}, stamp{1, "Asia/Tokyo"}},
+			expected: []any{stamp{1, "UTC"}, stamp{1, "Asia/Tokyo"}},
+		},
+		{
+			name: "Nested in structs",
+			input: []any{
+				event{Name: "deploy", At: stamp{1, "UTC"}, Label: &label{"Prod"}},
+				event{Name: "deploy", At: stamp{1, "Europe/Paris"}, Label: &label{"prod"}},
+				event{Name: "deploy", At: stamp{2, "UTC"}, Label: &label{"prod"}},
+				event{Name: "rollback", At: stamp{1, "UTC"}, Label: &label{"prod"}},
+				event{Name: "deploy", At: stamp{1, "UTC"}, Label: &label{"staging"}},
+				event{Name: "deploy", At: stamp{1, "UTC"}},
+				event{Name: "deploy", At: stamp{1, "Asia/Tokyo"}},
+			},
+			opts: []Option{UseEqualMethods()},
+			expected: []any{
+				event{Name: "deploy", At: stamp{1, "UTC"}, Label: &label{"Prod"}},
+				event{Name: "deploy", At: stamp{2, "UTC"}, Label: &label{"prod"}},
+				event{Name: "rollback", At: stamp{1, "UTC"}, Label: &label{"prod"}},
+				event{Name: "deploy", At: stamp{1, "UTC"}, Label: &label{"staging"}},
+				event{Name: "deploy", At: stamp{1, "UTC"}},
+			},
+		},
+		{
+			name:     "In slices and maps",
+			input:    []any{map[string][]stamp{"a": {{1, "UTC"}}}, map[string][]stamp{"a": {{1, "Asia/Tokyo"}}}, map[string][]stamp{"a": {{1, "UTC"}, {1, "UTC"}}}},
+			opts:     []Option{UseEqualMethods()},
+			expected: []any{map[string][]stamp{"a": {{1, "UTC"}}}, map[string][]stamp{"a": {{1, "UTC"}, {1, "UTC"}}}},
+		},
+		{
+			name: "Map values in key order",
+			input: []any{
+				map[string]stamp{"a": {1, "UTC"}, "b": {2, "UTC"}, "c": {3, "UTC"}, "d": {4, "UTC"}},
+				map[string]stamp{"d": {4, "Asia/Tokyo"}, "c": {3, "Asia/Tokyo"}, "b": {2, "Asia/Tokyo"}, "a": {1, "Asia/Tokyo"}},
+			},
+			opts:     []Option{UseEqualMethods()},
+			expected: []any{map[string]stamp{"a": {1, "UTC"}, "b": {2, "UTC"}, "c": {3, "UTC"}, "d": {4, "UTC"}}},
+		},
+		{
+			name:     "Equal method of the wrong form",
+			input:    []any{almost{1}, almost{2}, almost{1}},
+			opts:     []Option{UseEqualMethods()},
+			expected: []any{almost{1}, almost{2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := UniqueWithOptions(tt.input, tt.opts...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+// zone is a time zone name that Equal compares case-insensitively.
+type zone string
+
+func (z zone) Equal(other zone) bool {
+	return strings.EqualFold(string(z), string(other))
+}
+
+func TestUseEqualMethodsMapKeys(t *testing.T) {
+	// Shows that map keys are compared with ==, not with their Equal methods, so
+	// entries keep one order however the maps are iterated.
+	newMap := func() map[zone]int {
+		return map[zone]int{"UTC": 0, "utc": 1, "Asia/Tokyo": 9, "Europe/Paris": 2}
+	}
+	for range 100 {
+		result, err := UniqueWithOptions([]map[zone]int{newMap(), newMap()}, UseEqualMethods())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(result) != 1 {
+			t.Fatalf("expected 1 item, got %v", result)
+		}
+	}
+
+	result, err := UniqueWithOptions([]map[zone]int{{"UTC": 0}, {"utc": 0}}, UseEqualMethods())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result) != 2 {
+		t.Errorf("expected 2 items, got %v", result)
+	}
+}
+
+func TestUseEqualMethodsUnexportedField(t *testing.T) {
+	// Equal can't be called through an unexported field, so the field is
+	// compared by its own fields.
+	type holder struct {
+		at stamp
+	}
+
+	result, err := UniqueWithOptions([]holder{{stamp{1, "UTC"}}, {stamp{1, "Asia/Tokyo"}}}, UseEqualMethods())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result) != 2 {
+		t.Errorf("expected 2 items, got %v", result)
+	}
+}
+
+func TestMakeWithEqualMethods(t *testing.T) {
+	if _, err := MakeWithOptions(stamp{1, "UTC"}, UseEqualMethods()); err == nil {
+		t.Errorf("expected an error, got nil")
+	}
+}
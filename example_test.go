@@ -31,24 +31,19 @@ func ExampleUnique() {
 
 func ExampleMake() {
 	alice := "Alice"
-	deepHandle, deepPointers, err := Make(&alice)
+	deepHandle, err := Make(&alice)
 	if err != nil {
 		fmt.Println("Error:", err)
 		return
 	}
 
 	otherAlice := "Alice"
-	otherDeepHandle, otherDeepPointers, err := Make(&otherAlice)
+	otherDeepHandle, err := Make(&otherAlice)
 	if err != nil {
 		fmt.Println("Error:", err)
 		return
 	}
 
-	// Avoid "unused variable" errors. I hope this preserves the pointers through
-	// garbage collection.
-	_ = deepPointers
-	_ = otherDeepPointers
-
 	fmt.Println("alice and otherAlice have the same deep handle:", deepHandle == otherDeepHandle)
 	// Output: alice and otherAlice have the same deep handle: true
 }
@@ -0,0 +1,140 @@
+package deepunique
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"reflect"
+	"sync"
+)
+
+// fingerprintVersion is hashed before every fingerprint, so that a change to the
+// encoding can never be mistaken for the old one.
+const fingerprintVersion = "deepunique fingerprint v1\x00"
+
+// Fingerprint returns a SHA-256 digest of the canonical encoding of value. Unlike
+// handles it depends only on types and values, so it is the same across runs,
+// machines and goroutines and can be stored or shared between processes.
+//
+// Types are identified by their package path, name and structure. A fingerprint
+// changes if the type of the value, or of anything in it, is renamed, moved to
+// another package or changes kind; if a struct gains, loses, renames or reorders
+// fields, or changes a field's type, embedding or deepunique tag; or if an
+// array's length, a channel's direction, a func's signature or an interface's
+// methods change. Other struct tags and the methods of concrete types don't
+// matter.
+//
+// Since a fingerprint has to be a pure function of the content, every NaN is
+// treated as equal to every other NaN. Values compared by address (channels,
+// unsafe pointers and pointer map keys) and non-nil funcs, which are never
+// equal, can't be fingerprinted and return an error.
+func Fingerprint(value any) ([32]byte, error) {
+	e := getEncoder(defaultOptions)
+	defer putEncoder(e)
+	e.stable = true
+	e.buf = append(e.buf, fingerprintVersion...)
+	e.encodeRoot(reflect.ValueOf(value))
+	if err := e.error(); err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(e.buf), nil
+}
+
+var stableTypeDigests sync.Map // reflect.Type -> [sha256.Size]byte
+
+// stableTypeDigest returns a digest of a description of t that is the same in
+// every process.
+func stableTypeDigest(t reflect.Type) [sha256.Size]byte {
+	if digest, ok := stableTypeDigests.Load(t); ok {
+		return digest.([sha256.Size]byte)
+	}
+	d := typeDescriber{seen: make(map[reflect.Type]int)}
+	d.describe(t)
+	digest := sha256.Sum256(d.buf)
+	stableTypeDigests.Store(t, digest)
+	return digest
+}
+
+type typeDescriber struct {
+	buf []byte
+	// seen numbers the named types being described, so recursive types can
+	// refer back to them.
+	seen map[reflect.Type]int
+}
+
+func (d *typeDescriber) writeString(s string) {
+	d.buf = binary.AppendUvarint(d.buf, uint64(len(s)))
+	d.buf = append(d.buf, s...)
+}
+
+func (d *typeDescriber) writeUvarint(u uint64) {
+	d.buf = binary.AppendUvarint(d.buf, u)
+}
+
+func (d *typeDescriber) describe(t reflect.Type) {
+	if t.Name() != "" {
+		if i, ok := d.seen[t]; ok {
+			// 0 is never a kind, so this can't be mistaken for a type.
+			d.writeUvarint(0)
+			d.writeUvarint(uint64(i))
+			return
+		}
+		d.seen[t] = len(d.seen)
+	}
+
+	d.writeUvarint(uint64(t.Kind()))
+	d.writeString(t.PkgPath())
+	d.writeString(t.Name())
+
+	switch t.Kind() {
+	case reflect.Array:
+		d.writeUvarint(uint64(t.Len()))
+		d.describe(t.Elem())
+	case reflect.Slice, reflect.Pointer:
+		d.describe(t.Elem())
+	case reflect.Chan:
+		d.writeUvarint(uint64(t.ChanDir()))
+		d.describe(t.Elem())
+	case reflect.Map:
+		d.describe(t.Key())
+		d.describe(t.Elem())
+	case reflect.Struct:
+		d.writeUvarint(uint64(t.NumField()))
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			d.writeString(field.Name)
+			d.writeString(field.PkgPath)
+			// Only the deepunique tag changes how the field is compared, so other
+			// tags can be edited without changing fingerprints.
+			tag, _ := parseTag(field)
+			d.writeUvarint(uint64(tag))
+			if field.Anonymous {
+				d.writeUvarint(1)
+			} else {
+				d.writeUvarint(0)
+			}
+			d.describe(field.Type)
+		}
+	case reflect.Func:
+		d.writeUvarint(uint64(t.NumIn()))
+		for i := 0; i < t.NumIn(); i++ {
+			d.describe(t.In(i))
+		}
+		d.writeUvarint(uint64(t.NumOut()))
+		for i := 0; i < t.NumOut(); i++ {
+			d.describe(t.Out(i))
+		}
+		if t.IsVariadic() {
+			d.writeUvarint(1)
+		} else {
+			d.writeUvarint(0)
+		}
+	case reflect.Interface:
+		d.writeUvarint(uint64(t.NumMethod()))
+		for i := 0; i < t.NumMethod(); i++ {
+			method := t.Method(i)
+			d.writeString(method.Name)
+			d.writeString(method.PkgPath)
+			d.describe(method.Type)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// go_api/pkg/deepunique/fingerprint_test.go
+package deepunique
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+)
+
+type fingerprintFixture struct {
+	ID     int
+	Name   *string
+	Tags   []string
+	Labels map[string]any
+	Score  float64
+	Next   *fingerprintFixture
+}
+
+func newFingerprintFixture() *fingerprintFixture {
+	name := "alice"
+	fixture := &fingerprintFixture{
+		ID:     1,
+		Name:   &name,
+		Tags:   []string{"a", "b"},
+		Labels: map[string]any{"app": "deepunique", "replicas": 3, "ports": []int{80, 443}},
+		Score:  1.5,
+	}
+	fixture.Next = fixture
+	return fixture
+}
+
+func TestFingerprintGolden(t *testing.T) {
+	// Shows that fingerprints don't depend on the process. If this changes, stored
+	// fingerprints break, so fingerprintVersion has to change too.
+	const expected = "e607b35406a866e3407cc43e1ff5e043ece9a28c06506637432f8ee2b8741c2f"
+
+	fingerprint, err := Fingerprint(newFingerprintFixture())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if hex.EncodeToString(fingerprint[:]) != expected {
+		t.Errorf("expected %v, got %x", expected, fingerprint)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	alice := "Alice"
+	otherAlice := "Alice"
+
+	tests := []struct {
+		name     string
+		a        any
+		b        any
+		expected bool
+	}{
+		{name: "Equal fixtures", a: newFingerprintFixture(), b: newFingerprintFixture(), expected: true},
+		{name: "Pointers to the same value", a: &alice, b: &otherAlice, expected: true},
+		{name: "Maps in different insertion orders", a: map[string]int{"a": 1, "b": 2}, b: map[string]int{"b": 2, "a": 1}, expected: true},
+		{name: "NaN", a: math.NaN(), b: math.NaN(), expected: true},
+		{name: "Negative and positive zero", a: math.Copysign(0, -1), b: 0.0, expected: true},
+		{name: "Nil", a: nil, b: nil, expected: true},
+		{name: "Nil and empty slices", a: []int(nil), b: []int{}, expected: false},
+		{name: "Same value with different types", a: int32(1), b: int64(1), expected: false},
+		{name: "Different named types", a: map[string]any{"a": evilAlice()}, b: map[string]any{"a": "Alice"}, expected: false},
+		{name: "Different values", a: []string{"a", "b"}, b: []string{"a", "c"}, expected: false},
+		{name: "Different json tags", a: struct {
+			Name string `json:"name"`
+		}{"a"}, b: struct {
+			Name string `json:"full_name" db:"name"`
+		}{"a"}, expected: true},
+		{name: "Different deepunique tags", a: struct {
+			Name string `deepunique:"ci"`
+		}{"a"}, b: struct {
+			Name string
+		}{"a"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fingerprintA, err := Fingerprint(tt.a)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			fingerprintB, err := Fingerprint(tt.b)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if (fingerprintA == fingerprintB) != tt.expected {
+				t.Errorf("expected equal fingerprints to be %v, got %v", tt.expected, fingerprintA == fingerprintB)
+			}
+		})
+	}
+}
+
+func TestFingerprintNaNKeys(t *testing.T) {
+	// Shows that NaN keys, which fingerprint the same, still leave their entries
+	// in one order.
+	newMap := func(values ...int) map[float64]int {
+		m := map[float64]int{1: 0}
+		for _, v := range values {
+			m[math.NaN()] = v
+		}
+		return m
+	}
+
+	expected, err := Fingerprint(newMap(1, 2, 3))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	expectedHandle, err := MakeWithOptions(newMap(1, 2, 3), NaNEqualsNaN())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for range 200 {
+		fingerprint, err := Fingerprint(newMap(3, 1, 2))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if fingerprint != expected {
+			t.Fatalf("expected %x, got %x", expected, fingerprint)
+		}
+		handle, err := MakeWithOptions(newMap(2, 3, 1), NaNEqualsNaN())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if handle != expectedHandle {
+			t.Fatalf("expected %v, got %v", expectedHandle, handle)
+		}
+	}
+}
+
+func TestFingerprintConcurrent(t *testing.T) {
+	expected, err := Fingerprint(newFingerprintFixture())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fingerprint, err := Fingerprint(newFingerprintFixture())
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+				return
+			}
+			if fingerprint != expected {
+				t.Errorf("expected %x, got %x", expected, fingerprint)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFingerprintAddressErrors(t *testing.T) {
+	alice := "Alice"
+
+	type service struct {
+		Name   string
+		Events map[string][]chan int
+	}
+
+	tests := []struct {
+		name  string
+		value any
+		path  string
+	}{
+		{name: "Channel", value: make(chan int), path: ""},
+		{name: "Func", value: func() {}, path: ""},
+		{name: "Pointer map key", value: map[*string]int{&alice: 1}, path: fmt.Sprintf("[%v]", &alice)},
+		{name: "Nested channel", value: []any{1, make(chan int)}, path: "[1]"},
+		{
+			name:  "Deeply nested channel",
+			value: &service{Name: "api", Events: map[string][]chan int{"ready": {nil, make(chan int)}}},
+			path:  `.Events["ready"][1]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Fingerprint(tt.value)
+			var unsupported *UnsupportedValueError
+			if !errors.As(err, &unsupported) {
+				t.Fatalf("expected an UnsupportedValueError, got %v", err)
+			}
+			if unsupported.Path != tt.path {
+				t.Errorf("expected %v, got %v", tt.path, unsupported.Path)
+			}
+		})
+	}
+
+	// Nil values don't depend on an address.
+	if _, err := Fingerprint(struct {
+		C chan int
+		F func()
+	}{}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
@@ -1,5 +1,3 @@
 module github.com/Landeed/deepunique
 
-go 1.23.0
-
-toolchain go1.24.0
+go 1.24.0
@@ -0,0 +1,37 @@
+package deepunique
+
+// GroupDuplicates partitions the indexes of items into groups of deeply-equal
+// items. Groups are in the order their first item appears, and the indexes in
+// each group are in order. Items without duplicates get a group of their own.
+func GroupDuplicates[T any](items []T) ([][]int, error) {
+	seen := newSeenKeys[T](defaultOptions)
+	var groups [][]int
+
+	for i, item := range items {
+		group, first, err := seen.add(item)
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			groups = append(groups, nil)
+		}
+		groups[group] = append(groups[group], i)
+	}
+	return groups, nil
+}
+
+// GroupBy groups items by the deep identity of the keys extracted from them. Each
+// group keeps its items in order, and is keyed by the first key extracted for it.
+func GroupBy[T, K any](items []T, key func(T) K) (*DeepMap[K, []T], error) {
+	groups := NewDeepMap[K, []T]()
+	for _, item := range items {
+		k := key(item)
+		handle, err := Make(k)
+		if err != nil {
+			return nil, err
+		}
+		group := groups.entries[handle]
+		groups.setHandle(handle, k, append(group.value, item))
+	}
+	return groups, nil
+}
@@ -0,0 +1,94 @@
+// go_api/pkg/deepunique/group_test.go
+package deepunique
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupDuplicates(t *testing.T) {
+	alice := "Alice"
+	otherAlice := "Alice"
+	bob := "Bob"
+
+	type record struct {
+		ID   int
+		Name *string
+	}
+
+	tests := []struct {
+		name     string
+		input    []record
+		expected [][]int
+	}{
+		{name: "Empty", input: nil, expected: nil},
+		{
+			name:     "No duplicates",
+			input:    []record{{ID: 1, Name: &alice}, {ID: 2, Name: &bob}},
+			expected: [][]int{{0}, {1}},
+		},
+		{
+			name: "Duplicates having different pointers to same value",
+			input: []record{
+				{ID: 2, Name: &bob},
+				{ID: 1, Name: &alice},
+				{ID: 2, Name: &bob},
+				{ID: 1, Name: &otherAlice},
+				{ID: 3, Name: nil},
+				{ID: 1, Name: &alice},
+			},
+			expected: [][]int{{0, 2}, {1, 3, 5}, {4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups, err := GroupDuplicates(tt.input)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(groups, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, groups)
+			}
+		})
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	type event struct {
+		ID      int
+		Payload map[string]any
+	}
+
+	input := []event{
+		{ID: 1, Payload: map[string]any{"error": "timeout", "codes": []int{504}}},
+		{ID: 2, Payload: map[string]any{"error": "not found"}},
+		{ID: 3, Payload: map[string]any{"codes": []int{504}, "error": "timeout"}},
+		{ID: 4, Payload: nil},
+	}
+
+	groups, err := GroupBy(input, func(e event) map[string]any { return e.Payload })
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if groups.Len() != 3 {
+		t.Errorf("expected 3 groups, got %v", groups.Len())
+	}
+
+	group, exists, err := groups.Get(map[string]any{"error": "timeout", "codes": []int{504}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !exists {
+		t.Fatalf("expected a group for the timeout payload")
+	}
+	if len(group) != 2 || group[0].ID != 1 || group[1].ID != 3 {
+		t.Errorf("expected IDs 1 and 3, got %v", group)
+	}
+
+	for key, group := range groups.All() {
+		if !reflect.DeepEqual(key, group[0].Payload) {
+			t.Errorf("expected the group to be keyed by its first payload %v, got %v", group[0].Payload, key)
+		}
+	}
+}
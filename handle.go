@@ -0,0 +1,70 @@
+package deepunique
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"unique"
+	"weak"
+)
+
+// DeepHandle is a handle for the deep value of a T, the way unique.Handle is a
+// handle for a comparable value. Two DeepHandles are equal exactly when the
+// values they were made from are deeply equal, so they can be used as map keys.
+type DeepHandle[T any] struct {
+	entry *handleEntry[T]
+}
+
+// Value returns the canonical representative for the handle: the first value
+// that a handle with this deep identity was made from, as it is now rather than
+// as it was then. It is shared, so it must not be modified.
+func (h DeepHandle[T]) Value() T {
+	return h.entry.value
+}
+
+type handleEntry[T any] struct {
+	key   unique.Handle[string]
+	value T
+	// pins keep the values whose addresses are in key reachable, so the
+	// addresses can't be reused by other values while the handle exists.
+	pins []reflect.Value
+}
+
+type handleKey struct {
+	typ  reflect.Type
+	opts string
+	key  unique.Handle[string]
+}
+
+// handles maps a handleKey to a weak.Pointer to its *handleEntry, so entries go
+// away once no DeepHandle refers to them, the same as unique does internally.
+var handles sync.Map
+
+// makeHandle returns the DeepHandle for the canonical encoding key, using value
+// and its pins if there isn't an entry yet.
+func makeHandle[T any](key unique.Handle[string], opts *options, value T, pins []reflect.Value) DeepHandle[T] {
+	hk := handleKey{typ: reflect.TypeFor[T](), opts: opts.key, key: key}
+	for {
+		old, loaded := handles.Load(hk)
+		if loaded {
+			if entry := old.(weak.Pointer[handleEntry[T]]).Value(); entry != nil {
+				return DeepHandle[T]{entry}
+			}
+		}
+
+		entry := &handleEntry[T]{key: key, value: value, pins: pins}
+		wp := weak.Make(entry)
+		if loaded {
+			// The old entry was collected but its cleanup hasn't run yet.
+			if !handles.CompareAndSwap(hk, old, wp) {
+				continue
+			}
+		} else if _, loaded := handles.LoadOrStore(hk, wp); loaded {
+			continue
+		}
+		runtime.AddCleanup(entry, func(hk handleKey) {
+			handles.CompareAndDelete(hk, wp)
+		}, hk)
+		return DeepHandle[T]{entry}
+	}
+}
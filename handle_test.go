@@ -0,0 +1,147 @@
+// go_api/pkg/deepunique/handle_test.go
+package deepunique
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeepHandleMapKey(t *testing.T) {
+	// Shows that deep handles can key a strongly typed map.
+	cache := make(map[DeepHandle[[]string]]int)
+
+	paths := [][]string{
+		{"spec", "ports"},
+		{"spec", "labels"},
+		{"spec", "ports"},
+	}
+	for i, path := range paths {
+		handle, err := Make(path)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if _, exists := cache[handle]; !exists {
+			cache[handle] = i
+		}
+	}
+
+	if len(cache) != 2 {
+		t.Errorf("expected 2 entries, got %v", len(cache))
+	}
+
+	handle, err := Make([]string{"spec", "ports"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cache[handle] != 0 {
+		t.Errorf("expected %v, got %v", 0, cache[handle])
+	}
+}
+
+func TestDeepHandleValue(t *testing.T) {
+	// Shows that Value returns the first value the handle was made from. The name
+	// is new each run, so no handle from an earlier run can still be alive.
+	alice := fmt.Sprintf("Alice %d", time.Now().UnixNano())
+	otherAlice := strings.Clone(alice)
+
+	handle, err := Make(&alice)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	otherHandle, err := Make(&otherAlice)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if handle != otherHandle {
+		t.Errorf("expected %v, got %v", handle, otherHandle)
+	}
+	if otherHandle.Value() != &alice {
+		t.Errorf("expected %v, got %v", &alice, otherHandle.Value())
+	}
+}
+
+func TestDeepHandleTypes(t *testing.T) {
+	// Shows that handles for the same contents under different types stay separate,
+	// and each one's Value has the handle's type.
+	anyHandle, err := Make[any]([]int{1, 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	sliceHandle, err := Make([]int{1, 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if anyHandle.entry.key != sliceHandle.entry.key {
+		t.Errorf("expected the same encoding, got %v and %v", anyHandle.entry.key, sliceHandle.entry.key)
+	}
+	if value, ok := anyHandle.Value().([]int); !ok || len(value) != 2 {
+		t.Errorf("expected []int{1, 2}, got %v", anyHandle.Value())
+	}
+	if value := sliceHandle.Value(); len(value) != 2 {
+		t.Errorf("expected []int{1, 2}, got %v", value)
+	}
+}
+
+func TestDeepHandleKeepsAlive(t *testing.T) {
+	// Shows that a handle keeps the values its encoding depends on reachable,
+	// and that it stays stable across garbage collections.
+	collected := make(chan struct{})
+
+	// Pointer map keys are encoded by address, so the handle has to own them.
+	newHandle := func() DeepHandle[map[*[4]int64]string] {
+		key := new([4]int64)
+		runtime.AddCleanup(key, func(collected chan struct{}) {
+			close(collected)
+		}, collected)
+		handle, err := Make(map[*[4]int64]string{key: "alice"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return handle
+	}
+
+	handle := newHandle()
+	for i := 0; i < 3; i++ {
+		runtime.GC()
+	}
+	select {
+	case <-collected:
+		t.Fatalf("expected the key to stay reachable while the handle is held")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// A copy of the representative has the same keys, so it must get the same handle.
+	copied := make(map[*[4]int64]string)
+	for key, value := range handle.Value() {
+		copied[key] = value
+	}
+	otherHandle, err := Make(copied)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if handle != otherHandle {
+		t.Errorf("expected %v, got %v", handle, otherHandle)
+	}
+	copied = nil
+	runtime.KeepAlive(handle)
+
+	// Once the handles are gone, the key can be collected.
+	handle = DeepHandle[map[*[4]int64]string]{}
+	otherHandle = DeepHandle[map[*[4]int64]string]{}
+	deadline := time.After(5 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case <-collected:
+			return
+		case <-deadline:
+			t.Fatalf("expected the key to be collected once the handles were dropped")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
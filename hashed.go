@@ -0,0 +1,54 @@
+package deepunique
+
+import (
+	"bytes"
+	"hash/maphash"
+	"reflect"
+)
+
+// hashEncoding hashes an item's encoding. Tests replace it to force collisions.
+var hashEncoding = maphash.Bytes
+
+// UniqueHashed is like Unique, but only keeps a 64-bit hash of each distinct
+// item's encoding rather than the encoding itself, so the memory it needs doesn't
+// grow with the size of the items. When an item's hash matches an earlier one,
+// the earlier item is encoded again to check that they really are equal, so the
+// result is exactly the same as Unique's. That makes duplicates cost about twice
+// as much to find.
+func UniqueHashed[T any](items []T) ([]T, error) {
+	seed := maphash.MakeSeed()
+	// seen maps each hash to the items in result with it.
+	seen := make(map[uint64][]int)
+	result := make([]T, 0, len(items))
+
+	e := getEncoder(defaultOptions)
+	defer putEncoder(e)
+	other := getEncoder(defaultOptions)
+	defer putEncoder(other)
+
+	for _, item := range items {
+		e.reset()
+		e.encodeRoot(reflect.ValueOf(item))
+		if err := e.error(); err != nil {
+			return nil, err
+		}
+		hash := hashEncoding(seed, e.buf)
+
+		duplicate := false
+		for _, i := range seen[hash] {
+			// Items whose encodings depend on addresses are still reachable
+			// through items, so the addresses can't have been reused.
+			other.reset()
+			other.encodeRoot(reflect.ValueOf(result[i]))
+			if bytes.Equal(e.buf, other.buf) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			seen[hash] = append(seen[hash], len(result))
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
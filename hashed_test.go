@@ -0,0 +1,196 @@
+// go_api/pkg/deepunique/hashed_test.go
+package deepunique
+
+import (
+	"hash/maphash"
+	"math"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+type randomRecord struct {
+	ID     int
+	Tags   []string
+	Counts map[string]int
+	Parent *randomRecord
+	Extra  any
+}
+
+// sharedValues are reached through the same pointer, map or slice from different
+// items, so reflect.DeepEqual takes them to be equal to themselves without
+// looking inside, NaNs and all.
+var sharedValues = []any{
+	&[]float64{math.NaN()},
+	[]any{math.NaN(), "a"},
+	map[string]float64{"nan": math.NaN()},
+	&randomRecord{ID: 1, Extra: math.NaN()},
+}
+
+// randomValue returns a value built from a small set of parts, so that deeply
+// equal values come up often. Apart from sharedValues, each value is built
+// afresh without NaNs, since reflect.DeepEqual can't match up items with
+// NaNs it has to look inside.
+func randomValue(r *rand.Rand, depth int) any {
+	choice := r.IntN(10)
+	if depth <= 0 {
+		choice = r.IntN(4)
+	}
+	switch choice {
+	case 0:
+		return r.IntN(3)
+	case 1:
+		return []string{"a", "b", ""}[r.IntN(3)]
+	case 2:
+		return []float64{0, math.Copysign(0, -1), 1.5}[r.IntN(3)]
+	case 3:
+		return nil
+	case 4:
+		if r.IntN(4) == 0 {
+			return []any(nil)
+		}
+		items := make([]any, r.IntN(3))
+		for i := range items {
+			items[i] = randomValue(r, depth-1)
+		}
+		return items
+	case 5:
+		if r.IntN(4) == 0 {
+			return map[string]any(nil)
+		}
+		m := make(map[string]any)
+		for range r.IntN(3) {
+			m[[]string{"x", "y", "z"}[r.IntN(3)]] = randomValue(r, depth-1)
+		}
+		return m
+	case 6:
+		n := r.IntN(2)
+		return &n
+	case 7:
+		return sharedValues[r.IntN(len(sharedValues))]
+	default:
+		return randomRecordValue(r, depth-1)
+	}
+}
+
+func randomRecordValue(r *rand.Rand, depth int) randomRecord {
+	record := randomRecord{ID: r.IntN(2)}
+	if r.IntN(2) == 0 {
+		record.Tags = make([]string, r.IntN(3))
+		for i := range record.Tags {
+			record.Tags[i] = []string{"a", "b"}[r.IntN(2)]
+		}
+	}
+	if r.IntN(2) == 0 {
+		record.Counts = map[string]int{"n": r.IntN(2)}
+	}
+	if depth > 0 && r.IntN(3) == 0 {
+		parent := randomRecordValue(r, depth-1)
+		record.Parent = &parent
+	}
+	if depth > 0 && r.IntN(3) == 0 {
+		record.Extra = randomValue(r, depth-1)
+	}
+	return record
+}
+
+func TestUniqueHashedDifferential(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	for round := range 200 {
+		items := make([]any, 50)
+		for i := range items {
+			items[i] = randomValue(r, 3)
+		}
+
+		expected := SlowUnique(items)
+		unique, err := Unique(items)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		hashed, err := UniqueHashed(items)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !reflect.DeepEqual(unique, expected) {
+			t.Fatalf("round %d: expected Unique to return %v, got %v", round, expected, unique)
+		}
+		if !reflect.DeepEqual(hashed, expected) {
+			t.Fatalf("round %d: expected UniqueHashed to return %v, got %v", round, expected, hashed)
+		}
+	}
+}
+
+func TestUniqueHashedCollisions(t *testing.T) {
+	// Shows that items are still told apart when every hash collides.
+	hash := hashEncoding
+	hashEncoding = func(maphash.Seed, []byte) uint64 { return 0 }
+	defer func() { hashEncoding = hash }()
+
+	r := rand.New(rand.NewPCG(3, 4))
+	for round := range 50 {
+		items := make([]randomRecord, 30)
+		for i := range items {
+			items[i] = randomRecordValue(r, 2)
+		}
+
+		expected := SlowUnique(items)
+		hashed, err := UniqueHashed(items)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if !reflect.DeepEqual(hashed, expected) {
+			t.Fatalf("round %d: expected %v, got %v", round, expected, hashed)
+		}
+	}
+}
+
+func TestUniqueHashed(t *testing.T) {
+	alice := "Alice"
+	otherAlice := "Alice"
+	ring := &randomRecord{ID: 1}
+	ring.Parent = ring
+	otherRing := &randomRecord{ID: 1}
+	otherRing.Parent = otherRing
+
+	tests := []struct {
+		name     string
+		input    []any
+		expected []any
+	}{
+		{name: "Empty", input: nil, expected: []any{}},
+		{name: "Pointers to equal values", input: []any{&alice, &otherAlice}, expected: []any{&alice}},
+		{name: "NaN", input: []any{math.NaN(), math.NaN()}, expected: nil},
+		{name: "Cycles", input: []any{ring, otherRing}, expected: []any{ring}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := UniqueHashed(tt.input)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if tt.expected == nil {
+				// NaNs can't be compared with DeepEqual, so only count them.
+				if len(result) != len(tt.input) {
+					t.Errorf("expected %v items, got %v", len(tt.input), result)
+				}
+				return
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func BenchmarkUniqueHashed(b *testing.B) {
+	records := benchRecords(100_000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := UniqueHashed(records); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package deepunique
+
+import (
+	"reflect"
+	"sync"
+	"unique"
+	"unsafe"
+)
+
+// Pool interns values by deep equality, like unique.Make does for comparable
+// values. Every value interned in a Pool comes back as one shared representative
+// per deep identity, so repeated slices, maps and pointer graphs share memory.
+//
+// The representative is a deep copy the Pool makes of the first value interned
+// with that identity, so callers can go on modifying the values they intern.
+// The Pool keeps its representatives alive until it is dropped. The zero Pool is
+// ready to use, and a Pool is safe for concurrent use.
+type Pool[T any] struct {
+	mu sync.Mutex
+	// entries maps the canonical encoding of each distinct value to its
+	// representative.
+	entries map[unique.Handle[string]]poolEntry[T]
+}
+
+type poolEntry[T any] struct {
+	value T
+	// pins keep what the encoding refers to by address reachable, like a
+	// handle's do.
+	pins []reflect.Value
+}
+
+// Intern returns the canonical representative of value. Representatives are
+// shared, so they must not be modified.
+func (p *Pool[T]) Intern(value T) (T, error) {
+	key, _, _, err := makeKey(value, defaultOptions)
+	if err != nil {
+		return value, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if entry, ok := p.entries[key]; ok {
+		return entry.value, nil
+	}
+	if p.entries == nil {
+		p.entries = make(map[unique.Handle[string]]poolEntry[T])
+	}
+	representative := deepCopy(reflect.ValueOf(&value).Elem(), make(map[visit]reflect.Value)).Interface().(T)
+	// The copy is encoded again so that its pins come from it, and nothing keeps
+	// value itself reachable.
+	key, pins, _, err := makeKey(representative, defaultOptions)
+	if err != nil {
+		return value, err
+	}
+	p.entries[key] = poolEntry[T]{value: representative, pins: pins}
+	return representative, nil
+}
+
+// Len returns the number of distinct values in the pool.
+func (p *Pool[T]) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// deepCopy returns a copy of value that is deeply equal to it and shares none of
+// its memory, except where it is compared by address: map keys, channels, funcs,
+// unsafe pointers and fields tagged ptr. copied holds what has been copied so
+// far, so cycles and shared values come out the same way in the copy.
+func deepCopy(value reflect.Value, copied map[visit]reflect.Value) reflect.Value {
+	v, _, ok := enterCycle(value, nil)
+	if ok {
+		if c, ok := copied[v]; ok {
+			return c
+		}
+	}
+
+	t := value.Type()
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return value
+		}
+		c := reflect.New(t.Elem())
+		copied[v] = c
+		c.Elem().Set(deepCopy(value.Elem(), copied))
+		return c
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+		c := reflect.MakeSlice(t, value.Len(), value.Len())
+		if ok {
+			copied[v] = c
+		}
+		for i := range value.Len() {
+			c.Index(i).Set(deepCopy(value.Index(i), copied))
+		}
+		return c
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+		c := reflect.MakeMapWithSize(t, value.Len())
+		copied[v] = c
+		iter := value.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), deepCopy(iter.Value(), copied))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(t).Elem()
+		for i := range value.Len() {
+			c.Index(i).Set(deepCopy(value.Index(i), copied))
+		}
+		return c
+	case reflect.Struct:
+		// Unexported fields can only be read and set through their addresses.
+		if !value.CanAddr() {
+			addressable := reflect.New(t).Elem()
+			addressable.Set(value)
+			value = addressable
+		}
+		c := reflect.New(t).Elem()
+		info := structInfoOf(t)
+		for i, field := range info.fields {
+			src, dst := value.Field(i), c.Field(i)
+			if !field.exported {
+				src = reflect.NewAt(src.Type(), unsafe.Pointer(src.UnsafeAddr())).Elem()
+				dst = reflect.NewAt(dst.Type(), unsafe.Pointer(dst.UnsafeAddr())).Elem()
+			}
+			if field.tag&tagPtr != 0 {
+				dst.Set(src)
+				continue
+			}
+			dst.Set(deepCopy(src, copied))
+		}
+		return c
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+		c := reflect.New(t).Elem()
+		c.Set(deepCopy(value.Elem(), copied))
+		return c
+	default:
+		// Everything else either can't be modified, like strings, or is compared
+		// by address.
+		return value
+	}
+}
@@ -0,0 +1,175 @@
+// go_api/pkg/deepunique/intern_test.go
+package deepunique
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestPoolIntern(t *testing.T) {
+	type config struct {
+		Name     string
+		Hosts    []string
+		Limits   map[string]int
+		Fallback *config
+	}
+
+	newConfig := func() *config {
+		return &config{
+			Name:     "default",
+			Hosts:    []string{"a.example.com", "b.example.com"},
+			Limits:   map[string]int{"cpu": 2, "memory": 512},
+			Fallback: &config{Name: "fallback"},
+		}
+	}
+
+	var pool Pool[*config]
+	tenants := []*config{newConfig(), newConfig(), newConfig()}
+	interned := make([]*config, len(tenants))
+	for i, tenant := range tenants {
+		value, err := pool.Intern(tenant)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		interned[i] = value
+	}
+
+	for i := range interned {
+		if interned[i] != interned[0] {
+			t.Errorf("expected tenant %v to share %p, got %p", i, interned[0], interned[i])
+		}
+	}
+	if interned[0] == tenants[0] || !reflect.DeepEqual(interned[0], tenants[0]) {
+		t.Errorf("expected a copy of %v, got %p", tenants[0], interned[0])
+	}
+	if pool.Len() != 1 {
+		t.Errorf("expected 1 distinct value, got %v", pool.Len())
+	}
+
+	other := newConfig()
+	other.Hosts = append(other.Hosts, "c.example.com")
+	value, err := pool.Intern(other)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if value == interned[0] || !reflect.DeepEqual(value, other) {
+		t.Errorf("expected a copy of %v, got %v", other, value)
+	}
+	if pool.Len() != 2 {
+		t.Errorf("expected 2 distinct values, got %v", pool.Len())
+	}
+}
+
+func TestPoolKeepsRepresentatives(t *testing.T) {
+	// Shows that the pool keeps returning the same representative across collections,
+	// even once the caller has dropped it.
+	var pool Pool[[]string]
+	first, err := pool.Intern([]string{"a", "b"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	firstData := &first[0]
+	first = nil
+
+	runtime.GC()
+	runtime.GC()
+
+	second, err := pool.Intern([]string{"a", "b"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if &second[0] != firstData {
+		t.Errorf("expected the first representative to be returned, got a new one")
+	}
+}
+
+func TestPoolInternCopies(t *testing.T) {
+	// Shows that modifying a value after interning it doesn't reach the
+	// representative, including through cycles and unexported fields.
+	type node struct {
+		Name  string
+		Next  *node
+		items []int
+		Tags  map[string][]string
+		Extra any
+	}
+
+	newNode := func() *node {
+		n := &node{Name: "a", items: []int{1, 2}, Tags: map[string][]string{"k": {"v"}}, Extra: []any{"x"}}
+		n.Next = n
+		return n
+	}
+
+	var pool Pool[*node]
+	input := newNode()
+	first, err := pool.Intern(input)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if first.Next != first {
+		t.Errorf("expected the cycle to be kept in the copy, got %p", first.Next)
+	}
+
+	input.Name = "b"
+	input.items[0] = 9
+	input.Tags["k"][0] = "w"
+	input.Extra.([]any)[0] = "y"
+
+	second, err := pool.Intern(newNode())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if second != first {
+		t.Errorf("expected %p, got %p", first, second)
+	}
+	if !reflect.DeepEqual(second, newNode()) {
+		t.Errorf("expected %v, got %v", newNode(), second)
+	}
+
+	var slices Pool[[]int]
+	buf := []int{1, 2}
+	if _, err := slices.Intern(buf); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	buf[0] = 9
+	value, err := slices.Intern([]int{1, 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(value, []int{1, 2}) {
+		t.Errorf("expected %v, got %v", []int{1, 2}, value)
+	}
+}
+
+func TestPoolDropsOriginals(t *testing.T) {
+	// Shows that the pool only keeps its copy, so the value passed in can be
+	// collected while the pool is still in use.
+	var pool Pool[*[]string]
+	collected := make(chan struct{})
+	func() {
+		input := &[]string{"a", "b"}
+		runtime.AddCleanup(input, func(collected chan struct{}) {
+			close(collected)
+		}, collected)
+		if _, err := pool.Intern(input); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case <-collected:
+			if pool.Len() != 1 {
+				t.Errorf("expected 1 distinct value, got %v", pool.Len())
+			}
+			return
+		case <-deadline:
+			t.Fatalf("expected the value passed in to be collected")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
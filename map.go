@@ -0,0 +1,103 @@
+package deepunique
+
+import "iter"
+
+// DeepMap is a map whose keys are compared by deep equality, so slices, maps and
+// structs holding pointers can be keys. It keeps the first key set for each deep
+// identity.
+//
+// The zero DeepMap is ready to use. A DeepMap is not safe for concurrent use.
+type DeepMap[K any, V any] struct {
+	entries map[DeepHandle[K]]mapEntry[K, V]
+}
+
+type mapEntry[K any, V any] struct {
+	key   K
+	value V
+}
+
+func NewDeepMap[K any, V any]() *DeepMap[K, V] {
+	return &DeepMap[K, V]{entries: make(map[DeepHandle[K]]mapEntry[K, V])}
+}
+
+// Get returns the value for the key deeply equal to key, and whether there was one.
+func (m *DeepMap[K, V]) Get(key K) (V, bool, error) {
+	handle, err := Make(key)
+	if err != nil {
+		var zero V
+		return zero, false, err
+	}
+	entry, exists := m.entries[handle]
+	return entry.value, exists, nil
+}
+
+// Set sets the value for key. If there already is a deeply equal key, it is kept
+// and only the value is replaced.
+func (m *DeepMap[K, V]) Set(key K, value V) error {
+	handle, err := Make(key)
+	if err != nil {
+		return err
+	}
+	m.setHandle(handle, key, value)
+	return nil
+}
+
+func (m *DeepMap[K, V]) setHandle(handle DeepHandle[K], key K, value V) {
+	if m.entries == nil {
+		m.entries = make(map[DeepHandle[K]]mapEntry[K, V])
+	}
+	if entry, exists := m.entries[handle]; exists {
+		key = entry.key
+	}
+	m.entries[handle] = mapEntry[K, V]{key: key, value: value}
+}
+
+// Delete removes the key deeply equal to key, and reports whether there was one.
+func (m *DeepMap[K, V]) Delete(key K) (bool, error) {
+	handle, err := Make(key)
+	if err != nil {
+		return false, err
+	}
+	if _, exists := m.entries[handle]; !exists {
+		return false, nil
+	}
+	delete(m.entries, handle)
+	return true, nil
+}
+
+func (m *DeepMap[K, V]) Len() int {
+	return len(m.entries)
+}
+
+// All returns an iterator over the keys and values, in no particular order.
+func (m *DeepMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for _, entry := range m.entries {
+			if !yield(entry.key, entry.value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over the keys, in no particular order.
+func (m *DeepMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for _, entry := range m.entries {
+			if !yield(entry.key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values, in no particular order.
+func (m *DeepMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, entry := range m.entries {
+			if !yield(entry.value) {
+				return
+			}
+		}
+	}
+}
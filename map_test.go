@@ -0,0 +1,132 @@
+// go_api/pkg/deepunique/map_test.go
+package deepunique
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDeepMap(t *testing.T) {
+	type request struct {
+		Method string
+		Path   []string
+		Query  map[string][]string
+	}
+
+	newRequest := func() request {
+		return request{
+			Method: "GET",
+			Path:   []string{"v1", "users"},
+			Query:  map[string][]string{"limit": {"10"}},
+		}
+	}
+
+	var counts DeepMap[request, int]
+	for i := 0; i < 3; i++ {
+		count, _, err := counts.Get(newRequest())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if err := counts.Set(newRequest(), count+1); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	other := newRequest()
+	other.Query["limit"] = []string{"20"}
+	if err := counts.Set(other, 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if counts.Len() != 2 {
+		t.Errorf("expected 2 keys, got %v", counts.Len())
+	}
+
+	count, exists, err := counts.Get(newRequest())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !exists || count != 3 {
+		t.Errorf("expected 3, got %v (exists %v)", count, exists)
+	}
+
+	deleted, err := counts.Delete(other)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !deleted {
+		t.Errorf("expected the other request to be deleted")
+	}
+	_, exists, err = counts.Get(other)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if exists {
+		t.Errorf("expected the other request to be gone")
+	}
+	deleted, err = counts.Delete(other)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if deleted {
+		t.Errorf("expected the other request to be gone already")
+	}
+}
+
+func TestDeepMapKeepsFirstKey(t *testing.T) {
+	// Shows that setting a deeply equal key replaces the value but keeps the first key.
+	alice := "Alice"
+	otherAlice := "Alice"
+
+	m := NewDeepMap[*string, int]()
+	if err := m.Set(&alice, 1); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := m.Set(&otherAlice, 2); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for key, value := range m.All() {
+		if key != &alice {
+			t.Errorf("expected %v, got %v", &alice, key)
+		}
+		if value != 2 {
+			t.Errorf("expected %v, got %v", 2, value)
+		}
+	}
+}
+
+func TestDeepMapIterators(t *testing.T) {
+	m := NewDeepMap[[]string, int]()
+	for i, key := range [][]string{{"a"}, {"b"}, {"c"}} {
+		if err := m.Set(key, i); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	keys := slices.Collect(m.Keys())
+	slices.SortFunc(keys, slices.Compare)
+	if expected := [][]string{{"a"}, {"b"}, {"c"}}; !slices.EqualFunc(keys, expected, slices.Equal) {
+		t.Errorf("expected %v, got %v", expected, keys)
+	}
+
+	values := slices.Sorted(m.Values())
+	if expected := []int{0, 1, 2}; !slices.Equal(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+
+	for key, value := range m.All() {
+		if key[0] != string(rune('a'+value)) {
+			t.Errorf("expected %v to map to %v", key, string(rune('a'+value)))
+		}
+	}
+
+	count := 0
+	for range m.All() {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Errorf("expected iteration to stop after 1, got %v", count)
+	}
+}
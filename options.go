@@ -0,0 +1,182 @@
+package deepunique
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// An Option changes which values are considered equal, by changing their
+// canonical encoding. By default the semantics are those of reflect.DeepEqual.
+type Option func(*options)
+
+type options struct {
+	nilEqualsEmpty   bool
+	nanEqualsNaN     bool
+	ignoreUnexported bool
+	equalMethods     bool
+	unorderedSlices  bool
+	unorderedTypes   map[reflect.Type]bool
+	ignoreFields     []string
+	unorderedFields  []string
+
+	// fields is the root of the ignored and unordered field paths, or nil if
+	// there are none.
+	fields *fieldNode
+	// key identifies the semantics, so handles made with different options
+	// are never mixed up.
+	key string
+}
+
+var defaultOptions = &options{}
+
+// NilEqualsEmpty treats nil slices and maps as equal to empty ones.
+func NilEqualsEmpty() Option {
+	return func(o *options) { o.nilEqualsEmpty = true }
+}
+
+// NaNEqualsNaN treats every NaN as equal to every other NaN.
+func NaNEqualsNaN() Option {
+	return func(o *options) { o.nanEqualsNaN = true }
+}
+
+// IgnoreUnexported leaves unexported struct fields out of equality.
+func IgnoreUnexported() Option {
+	return func(o *options) { o.ignoreUnexported = true }
+}
+
+// UseEqualMethods compares values whose type has a method of the form
+// func (T) Equal(T) bool with that method, the way go-cmp does, instead of by
+// their fields. An Equal method gives no canonical key, so candidates are
+// grouped by the rest of their encoding and then checked with Equal. That makes
+// it only usable where all the values are at hand, like UniqueWithOptions;
+// MakeWithOptions returns an error for it. Map keys are still compared with ==.
+//
+// Equal should be an equivalence relation, or which values are kept depends on
+// their order.
+func UseEqualMethods() Option {
+	return func(o *options) { o.equalMethods = true }
+}
+
+// UnorderedSlices compares every slice and array as a multiset, so [a b a] and
+// [b a a] are equal but [a b b] isn't. Byte slices and arrays are usually data
+// rather than collections, so they keep their order unless named with
+// UnorderedSlicesOf.
+func UnorderedSlices() Option {
+	return func(o *options) { o.unorderedSlices = true }
+}
+
+// UnorderedSlicesOf compares slices and arrays of type T as multisets, wherever
+// they are. T should be a slice or array type, like []string or a named
+// Hostnames type; other types are unaffected.
+func UnorderedSlicesOf[T any]() Option {
+	return func(o *options) {
+		if o.unorderedTypes == nil {
+			o.unorderedTypes = make(map[reflect.Type]bool)
+		}
+		o.unorderedTypes[reflect.TypeFor[T]()] = true
+	}
+}
+
+// UnorderedFields compares the slices and arrays in the struct fields at the
+// given paths as multisets, including ones nested in them. Paths are the same as
+// for IgnoreFields. The `deepunique:"set"` tag does the same for a field of your
+// own type.
+func UnorderedFields(paths ...string) Option {
+	return func(o *options) { o.unorderedFields = append(o.unorderedFields, paths...) }
+}
+
+// IgnoreFields leaves the struct fields at the given paths out of equality.
+// A path is a dot-separated list of field names starting from the value passed
+// in, such as "Spec.UpdatedAt". Pointers, interfaces, slices, arrays and map
+// values don't add to the path, so "Items.UpdatedAt" ignores UpdatedAt in every
+// element of Items. Embedded fields are named by their type.
+func IgnoreFields(paths ...string) Option {
+	return func(o *options) { o.ignoreFields = append(o.ignoreFields, paths...) }
+}
+
+func newOptions(opts []Option) *options {
+	if len(opts) == 0 {
+		return defaultOptions
+	}
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	slices.Sort(o.ignoreFields)
+	o.ignoreFields = slices.Compact(o.ignoreFields)
+	slices.Sort(o.unorderedFields)
+	o.unorderedFields = slices.Compact(o.unorderedFields)
+	if len(o.ignoreFields) > 0 || len(o.unorderedFields) > 0 {
+		o.fields = &fieldNode{}
+	}
+	for _, path := range o.ignoreFields {
+		o.fields.add(strings.Split(path, ".")).ignore = true
+	}
+	for _, path := range o.unorderedFields {
+		o.fields.add(strings.Split(path, ".")).unordered = true
+	}
+
+	var key strings.Builder
+	for _, flag := range []bool{o.nilEqualsEmpty, o.nanEqualsNaN, o.ignoreUnexported, o.equalMethods, o.unorderedSlices} {
+		if flag {
+			key.WriteByte('1')
+		} else {
+			key.WriteByte('0')
+		}
+	}
+	for _, path := range o.ignoreFields {
+		key.WriteByte(0)
+		key.WriteString(path)
+	}
+	for _, path := range o.unorderedFields {
+		key.WriteByte(1)
+		key.WriteString(path)
+	}
+	// Types are named by their ids, since type names aren't unique.
+	ids := make([]uint64, 0, len(o.unorderedTypes))
+	for t := range o.unorderedTypes {
+		ids = append(ids, typeID(t))
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		key.WriteByte(2)
+		key.WriteString(strconv.FormatUint(id, 10))
+	}
+	o.key = key.String()
+	return o
+}
+
+// fieldNode is a trie of ignored and unordered field paths.
+type fieldNode struct {
+	ignore    bool
+	unordered bool
+	children  map[string]*fieldNode
+}
+
+// add returns the node at path below n, adding it if it isn't there.
+func (n *fieldNode) add(path []string) *fieldNode {
+	if len(path) == 0 {
+		return n
+	}
+	if n.children == nil {
+		n.children = make(map[string]*fieldNode)
+	}
+	child := n.children[path[0]]
+	if child == nil {
+		child = &fieldNode{}
+		n.children[path[0]] = child
+	}
+	return child.add(path[1:])
+}
+
+// child returns the node for the field name below n, or nil if no ignored path
+// goes through it. It is safe to call on a nil node.
+func (n *fieldNode) child(name string) *fieldNode {
+	if n == nil {
+		return nil
+	}
+	return n.children[name]
+}
@@ -0,0 +1,289 @@
+// go_api/pkg/deepunique/options_test.go
+package deepunique
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMakeWithOptions(t *testing.T) {
+	type status struct {
+		UpdatedAt int
+		Ready     bool
+	}
+	type item struct {
+		Name      string
+		UpdatedAt int
+	}
+	type resource struct {
+		Name    string
+		Spec    map[string]string
+		Status  status
+		Items   []item
+		version int
+	}
+
+	tests := []struct {
+		name     string
+		a        any
+		b        any
+		opts     []Option
+		expected bool
+	}{
+		{name: "Nil and empty slices", a: []int(nil), b: []int{}, expected: false},
+		{name: "Nil and empty slices with NilEqualsEmpty", a: []int(nil), b: []int{}, opts: []Option{NilEqualsEmpty()}, expected: true},
+		{name: "Nil and empty maps with NilEqualsEmpty", a: map[string]int(nil), b: map[string]int{}, opts: []Option{NilEqualsEmpty()}, expected: true},
+		{
+			name:     "Nested nil and empty with NilEqualsEmpty",
+			a:        resource{Name: "a", Items: nil},
+			b:        resource{Name: "a", Items: []item{}, Spec: map[string]string{}},
+			opts:     []Option{NilEqualsEmpty()},
+			expected: true,
+		},
+		{name: "Nil and non-nil pointers with NilEqualsEmpty", a: (*int)(nil), b: new(int), opts: []Option{NilEqualsEmpty()}, expected: false},
+		{name: "NaN", a: math.NaN(), b: math.NaN(), expected: false},
+		{name: "NaN with NaNEqualsNaN", a: math.NaN(), b: math.NaN(), opts: []Option{NaNEqualsNaN()}, expected: true},
+		{name: "NaN and a number with NaNEqualsNaN", a: []float64{math.NaN()}, b: []float64{0}, opts: []Option{NaNEqualsNaN()}, expected: false},
+		{name: "Float32 NaN with NaNEqualsNaN", a: float32(math.NaN()), b: float32(math.Inf(1) - math.Inf(1)), opts: []Option{NaNEqualsNaN()}, expected: true},
+		{name: "Unexported fields", a: resource{Name: "a", version: 1}, b: resource{Name: "a", version: 2}, expected: false},
+		{name: "Unexported fields with IgnoreUnexported", a: resource{Name: "a", version: 1}, b: resource{Name: "a", version: 2}, opts: []Option{IgnoreUnexported()}, expected: true},
+		{
+			name:     "Ignored field",
+			a:        resource{Name: "a", Status: status{UpdatedAt: 1, Ready: true}},
+			b:        resource{Name: "a", Status: status{UpdatedAt: 2}},
+			opts:     []Option{IgnoreFields("Status")},
+			expected: true,
+		},
+		{
+			name:     "Ignored nested field",
+			a:        &resource{Name: "a", Status: status{UpdatedAt: 1, Ready: true}},
+			b:        &resource{Name: "a", Status: status{UpdatedAt: 2, Ready: true}},
+			opts:     []Option{IgnoreFields("Status.UpdatedAt")},
+			expected: true,
+		},
+		{
+			name:     "Ignored nested field leaves siblings",
+			a:        resource{Name: "a", Status: status{UpdatedAt: 1, Ready: true}},
+			b:        resource{Name: "a", Status: status{UpdatedAt: 2, Ready: false}},
+			opts:     []Option{IgnoreFields("Status.UpdatedAt")},
+			expected: false,
+		},
+		{
+			name:     "Ignored field in slice elements",
+			a:        resource{Items: []item{{Name: "x", UpdatedAt: 1}, {Name: "y", UpdatedAt: 2}}},
+			b:        resource{Items: []item{{Name: "x", UpdatedAt: 3}, {Name: "y", UpdatedAt: 4}}},
+			opts:     []Option{IgnoreFields("Items.UpdatedAt")},
+			expected: true,
+		},
+		{
+			name:     "Ignored field only at its path",
+			a:        []any{item{Name: "x", UpdatedAt: 1}},
+			b:        []any{item{Name: "x", UpdatedAt: 2}},
+			opts:     []Option{IgnoreFields("Items.UpdatedAt")},
+			expected: false,
+		},
+		{
+			name:     "Combined options",
+			a:        resource{Name: "a", Status: status{UpdatedAt: 1}, version: 1},
+			b:        resource{Name: "a", Status: status{UpdatedAt: 2}, Items: []item{}, version: 2},
+			opts:     []Option{NilEqualsEmpty(), IgnoreUnexported(), IgnoreFields("Status.UpdatedAt")},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handleA, err := MakeWithOptions(tt.a, tt.opts...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			handleB, err := MakeWithOptions(tt.b, tt.opts...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if (handleA == handleB) != tt.expected {
+				t.Errorf("expected equal handles to be %v, got %v", tt.expected, handleA == handleB)
+			}
+		})
+	}
+}
+
+type hostnames []string
+
+func TestUnorderedSlices(t *testing.T) {
+	type permission struct {
+		Resource string
+		Verbs    []string
+	}
+	type role struct {
+		Name        string
+		Hosts       hostnames
+		Permissions []permission
+		Checksum    []byte
+		Order       []int
+	}
+
+	tests := []struct {
+		name     string
+		a        any
+		b        any
+		opts     []Option
+		expected bool
+	}{
+		{name: "Without an option", a: []string{"a", "b"}, b: []string{"b", "a"}, expected: false},
+		{name: "Global", a: []string{"a", "b", "a"}, b: []string{"b", "a", "a"}, opts: []Option{UnorderedSlices()}, expected: true},
+		{name: "Global keeps multiplicity", a: []string{"a", "b", "b"}, b: []string{"b", "a", "a"}, opts: []Option{UnorderedSlices()}, expected: false},
+		{name: "Global arrays", a: [3]int{1, 2, 3}, b: [3]int{3, 1, 2}, opts: []Option{UnorderedSlices()}, expected: true},
+		{name: "Global nested", a: [][]int{{1, 2}, {3}}, b: [][]int{{3}, {2, 1}}, opts: []Option{UnorderedSlices()}, expected: true},
+		{name: "Global keeps byte order", a: []byte("ab"), b: []byte("ba"), opts: []Option{UnorderedSlices()}, expected: false},
+		{
+			name:     "Global in structs",
+			a:        role{Permissions: []permission{{"pods", []string{"get", "list"}}, {"nodes", []string{"get"}}}},
+			b:        role{Permissions: []permission{{"nodes", []string{"get"}}, {"pods", []string{"list", "get"}}}},
+			opts:     []Option{UnorderedSlices()},
+			expected: true,
+		},
+		{
+			name:     "By type",
+			a:        role{Hosts: hostnames{"a.example", "b.example"}, Order: []int{1, 2}},
+			b:        role{Hosts: hostnames{"b.example", "a.example"}, Order: []int{1, 2}},
+			opts:     []Option{UnorderedSlicesOf[hostnames]()},
+			expected: true,
+		},
+		{
+			name:     "By type leaves other types",
+			a:        role{Order: []int{1, 2}},
+			b:        role{Order: []int{2, 1}},
+			opts:     []Option{UnorderedSlicesOf[hostnames]()},
+			expected: false,
+		},
+		{name: "By byte slice type", a: []byte("ab"), b: []byte("ba"), opts: []Option{UnorderedSlicesOf[[]byte]()}, expected: true},
+		{
+			name:     "By field",
+			a:        role{Order: []int{1, 2}, Checksum: []byte{1, 2}},
+			b:        role{Order: []int{2, 1}, Checksum: []byte{1, 2}},
+			opts:     []Option{UnorderedFields("Order")},
+			expected: true,
+		},
+		{
+			name:     "By field leaves other fields",
+			a:        role{Order: []int{1, 2}, Hosts: hostnames{"a", "b"}},
+			b:        role{Order: []int{2, 1}, Hosts: hostnames{"b", "a"}},
+			opts:     []Option{UnorderedFields("Order")},
+			expected: false,
+		},
+		{
+			name:     "By field nested",
+			a:        []role{{Permissions: []permission{{"pods", []string{"get", "list"}}, {"nodes", nil}}}},
+			b:        []role{{Permissions: []permission{{"nodes", nil}, {"pods", []string{"list", "get"}}}}},
+			opts:     []Option{UnorderedFields("Permissions")},
+			expected: true,
+		},
+		{
+			name:     "By nested field path",
+			a:        role{Permissions: []permission{{"pods", []string{"get", "list"}}, {"nodes", nil}}},
+			b:        role{Permissions: []permission{{"nodes", nil}, {"pods", []string{"list", "get"}}}},
+			opts:     []Option{UnorderedFields("Permissions.Verbs")},
+			expected: false,
+		},
+		{name: "Global leaves map keys", a: map[[2]int]bool{{1, 2}: true}, b: map[[2]int]bool{{2, 1}: true}, opts: []Option{UnorderedSlices()}, expected: false},
+		{
+			name:     "Nil and empty",
+			a:        role{Order: nil},
+			b:        role{Order: []int{}},
+			opts:     []Option{UnorderedSlices(), NilEqualsEmpty()},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handleA, err := MakeWithOptions(tt.a, tt.opts...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			handleB, err := MakeWithOptions(tt.b, tt.opts...)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if (handleA == handleB) != tt.expected {
+				t.Errorf("expected equal handles to be %v, got %v", tt.expected, handleA == handleB)
+			}
+		})
+	}
+}
+
+func TestMakeWithOptionsSeparatesHandles(t *testing.T) {
+	// Shows that handles made with different options are never equal, even when
+	// the encodings are.
+	handle, err := Make([]int{1})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	otherHandle, err := MakeWithOptions([]int{1}, NilEqualsEmpty())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if handle == otherHandle {
+		t.Errorf("expected different handles, got %v", handle)
+	}
+
+	// The order options are given in doesn't matter.
+	handle, err = MakeWithOptions([]int{1}, IgnoreFields("A", "B"), NilEqualsEmpty())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	otherHandle, err = MakeWithOptions([]int{1}, NilEqualsEmpty(), IgnoreFields("B"), IgnoreFields("A"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if handle != otherHandle {
+		t.Errorf("expected %v, got %v", handle, otherHandle)
+	}
+
+	// Ignoring a field and making it unordered aren't the same.
+	handle, err = MakeWithOptions([]int{1}, IgnoreFields("A"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	otherHandle, err = MakeWithOptions([]int{1}, UnorderedFields("A"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if handle == otherHandle {
+		t.Errorf("expected different handles, got %v", handle)
+	}
+}
+
+func TestUniqueWithOptions(t *testing.T) {
+	type payload struct {
+		ID    int
+		Tags  []string
+		Attrs map[string]string
+	}
+
+	input := []payload{
+		{ID: 1, Tags: nil},
+		{ID: 1, Tags: []string{}, Attrs: map[string]string{}},
+		{ID: 2, Tags: []string{"a"}},
+	}
+
+	result, err := Unique(input)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result) != 3 {
+		t.Errorf("expected length %v, got %v", 3, len(result))
+	}
+
+	result, err = UniqueWithOptions(input, NilEqualsEmpty())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result) != 2 {
+		t.Errorf("expected length %v, got %v", 2, len(result))
+	}
+	if result[0].Tags != nil || result[1].ID != 2 {
+		t.Errorf("expected the first of each to be kept, got %v", result)
+	}
+}
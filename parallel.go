@@ -0,0 +1,85 @@
+package deepunique
+
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unique"
+)
+
+// parallelChunk is how many items a worker encodes at a time. Chunks keep the
+// workers from contending on the counter, and are small enough to balance out
+// items that take longer than others.
+const parallelChunk = 256
+
+// UniqueParallel is like Unique, but encodes the items on up to workers
+// goroutines at once. Items are then kept or dropped in their original order, so
+// the result is exactly Unique's. If workers is 0 or less, GOMAXPROCS is used.
+//
+// Encoding is most of Unique's work, so this pays off for large slices of
+// nested values. For small or plain items, Unique is likely faster.
+func UniqueParallel[T any](items []T, workers int) ([]T, error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, (len(items)+parallelChunk-1)/parallelChunk)
+
+	keys := make([]unique.Handle[string], len(items))
+	var (
+		next atomic.Int64
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		// failed is the index of the first item that couldn't be encoded, so the
+		// error is the same one Unique would return.
+		failed = len(items)
+		err    error
+		pins   []reflect.Value
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var workerPins []reflect.Value
+			for {
+				start := int(next.Add(1)-1) * parallelChunk
+				if start >= len(items) {
+					break
+				}
+				for i := start; i < min(start+parallelChunk, len(items)); i++ {
+					key, itemPins, _, itemErr := makeKey(items[i], defaultOptions)
+					if itemErr != nil {
+						mu.Lock()
+						if i < failed {
+							failed, err = i, itemErr
+						}
+						mu.Unlock()
+						break
+					}
+					keys[i] = key
+					workerPins = append(workerPins, itemPins...)
+				}
+			}
+			mu.Lock()
+			pins = append(pins, workerPins...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[unique.Handle[string]]struct{})
+	result := make([]T, 0, len(items))
+	for i, key := range keys {
+		if _, exists := seen[key]; !exists {
+			seen[key] = struct{}{}
+			result = append(result, items[i])
+		}
+	}
+	// The keys of values compared by address are only valid while those values
+	// can't be freed and their addresses reused.
+	runtime.KeepAlive(pins)
+	return result, nil
+}
@@ -0,0 +1,83 @@
+// go_api/pkg/deepunique/parallel_test.go
+package deepunique
+
+import (
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestUniqueParallel(t *testing.T) {
+	r := rand.New(rand.NewPCG(5, 6))
+	large := make([]any, 5000)
+	for i := range large {
+		large[i] = randomValue(r, 3)
+	}
+
+	tests := []struct {
+		name    string
+		input   []any
+		workers int
+	}{
+		{name: "Empty", input: nil, workers: 4},
+		{name: "One item", input: large[:1], workers: 4},
+		{name: "Fewer items than a chunk", input: large[:100], workers: 4},
+		{name: "One worker", input: large, workers: 1},
+		{name: "Many workers", input: large, workers: 16},
+		{name: "GOMAXPROCS workers", input: large, workers: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, err := Unique(tt.input)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			result, err := UniqueParallel(tt.input, tt.workers)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(result, expected) {
+				t.Errorf("expected %v, got %v", expected, result)
+			}
+		})
+	}
+}
+
+func TestUniqueParallelError(t *testing.T) {
+	// Shows that the error is the one for the first bad item, the same as Unique.
+	type badName struct {
+		Name string `deepunique:"ptr"`
+	}
+	type badLabels struct {
+		Labels map[string]string `deepunique:"set"`
+	}
+
+	items := make([]any, 2000)
+	for i := range items {
+		items[i] = i % 10
+	}
+	items[1500] = badName{}
+	items[700] = badLabels{}
+
+	_, err := UniqueParallel(items, 8)
+	var unsupported *UnsupportedValueError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("expected an UnsupportedValueError, got %v", err)
+	}
+	if unsupported.Path != ".Labels" {
+		t.Errorf("expected %v, got %v", ".Labels", unsupported.Path)
+	}
+}
+
+func BenchmarkUniqueParallel(b *testing.B) {
+	records := benchRecords(100_000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := UniqueParallel(records, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
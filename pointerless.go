@@ -1,6 +1,15 @@
 package deepunique
 
-import "unique"
+import (
+	"reflect"
+	"sync"
+	"unique"
+)
+
+// DeduplicatePointerless and UniquePointerless compare items with ==. Unique
+// does the same by itself for types where that gives the same result, so they
+// are only needed where == is wanted instead, like for pointers that should be
+// compared by address.
 
 func DeduplicatePointerless[T comparable](items []T) []T {
 	// tech debt: simplify when go 1.23 is supported
@@ -33,3 +42,47 @@ func UniquePointerless[T comparable](items []T) []T {
 
 	return result
 }
+
+var plainTypes sync.Map // reflect.Type -> bool
+
+// isPlain reports whether two values of type t are deeply equal exactly when
+// they are ==, so they can be compared without being encoded. Plain types are
+// built from bools, numbers, strings, arrays and structs. Floats are plain since
+// == agrees with reflect.DeepEqual on them: NaN is never equal, not even to
+// itself, and -0 equals 0. Types with canonicalizers or struct tags aren't,
+// since those change what equal means.
+func isPlain(t reflect.Type) bool {
+	if plain, ok := plainTypes.Load(t); ok {
+		return plain.(bool)
+	}
+	plain := computePlain(t)
+	plainTypes.Store(t, plain)
+	return plain
+}
+
+func computePlain(t reflect.Type) bool {
+	if _, ok := canonicalizer(t); ok {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	case reflect.Array:
+		return computePlain(t.Elem())
+	case reflect.Struct:
+		if structInfoOf(t).tagged {
+			return false
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if !computePlain(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
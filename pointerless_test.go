@@ -2,6 +2,8 @@
 package deepunique
 
 import (
+	"math"
+	"reflect"
 	"testing"
 )
 
@@ -222,3 +224,131 @@ func TestUniqueWithPointers(t *testing.T) {
 		})
 	}
 }
+
+func TestIsPlain(t *testing.T) {
+	type point struct {
+		X, Y int
+		name string
+	}
+	type tagged struct {
+		Name string `deepunique:"ci"`
+	}
+	type keyed struct {
+		Inner cachedName
+	}
+
+	tests := []struct {
+		name     string
+		value    any
+		expected bool
+	}{
+		{name: "Int", value: 1, expected: true},
+		{name: "String", value: "a", expected: true},
+		{name: "Struct", value: point{}, expected: true},
+		{name: "Array of structs", value: [2]point{}, expected: true},
+		{name: "Float", value: 1.5, expected: true},
+		{name: "Struct with a float", value: struct{ F float32 }{}, expected: true},
+		{name: "Complex", value: 1i, expected: true},
+		{name: "Pointer", value: new(int), expected: false},
+		{name: "Slice", value: []int{}, expected: false},
+		{name: "Struct with an interface", value: struct{ V any }{}, expected: false},
+		{name: "Tagged struct", value: tagged{}, expected: false},
+		{name: "DeepKeyer field", value: keyed{}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if plain := isPlain(reflect.TypeOf(tt.value)); plain != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, plain)
+			}
+		})
+	}
+}
+
+func TestUniquePlainMatchesEncoding(t *testing.T) {
+	// Shows that taking the fast path doesn't change what Unique returns.
+	type point struct {
+		X, Y int
+		Name string
+	}
+	type tagged struct {
+		Name string `deepunique:"ci"`
+	}
+	type measure struct {
+		Value float64
+	}
+
+	tests := []struct {
+		name     string
+		input    []any
+		expected []any
+	}{
+		{name: "Ints", input: []any{1, 2, 1, 3, 2}, expected: []any{1, 2, 3}},
+		{name: "Same value, different types", input: []any{1, int64(1), uint(1), 1}, expected: []any{1, int64(1), uint(1)}},
+		{
+			name:     "Plain and encoded values mixed",
+			input:    []any{point{1, 2, "a"}, []int{1}, point{1, 2, "a"}, []int{1}, nil, point{2, 1, "a"}, nil},
+			expected: []any{point{1, 2, "a"}, []int{1}, nil, point{2, 1, "a"}},
+		},
+		{name: "Negative zero", input: []any{0.0, math.Copysign(0, -1), point{}}, expected: []any{0.0, point{}}},
+		{name: "Structs with NaN", input: []any{measure{math.NaN()}, measure{math.NaN()}}, expected: nil},
+		{name: "Tagged structs aren't compared with ==", input: []any{tagged{"A"}, tagged{"a"}}, expected: []any{tagged{"A"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Unique(tt.input)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			expected := len(tt.expected)
+			if tt.expected == nil {
+				// NaNs can't be compared with DeepEqual, so only count them.
+				expected = len(tt.input)
+				if len(result) != expected {
+					t.Errorf("expected %v items, got %v", expected, result)
+				}
+			} else if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+
+			groups, err := GroupDuplicates(tt.input)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(groups) != expected {
+				t.Errorf("expected %v groups, got %v", expected, groups)
+			}
+		})
+	}
+
+	points := []point{{1, 2, "a"}, {1, 2, "a"}, {2, 2, "b"}}
+	result, err := Unique(points)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if expected := SlowUnique(points); !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
+
+func TestUniquePlainAllocs(t *testing.T) {
+	// Shows that plain items are only copied to the heap when they are first seen.
+	type point struct {
+		X, Y int
+		Tag  string
+	}
+	points := make([]point, 1000)
+	for i := range points {
+		points[i] = point{X: i % 10, Tag: "p"}
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		if _, err := Unique(points); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+	if allocs > 50 {
+		t.Errorf("expected at most 50 allocations, got %v", allocs)
+	}
+}
@@ -0,0 +1,101 @@
+package deepunique
+
+import (
+	"iter"
+	"maps"
+)
+
+// DeepSet is a set of values compared by deep equality. It keeps the first value
+// added for each deep identity.
+//
+// The zero DeepSet is ready to use. A DeepSet is not safe for concurrent use.
+type DeepSet[T any] struct {
+	items map[DeepHandle[T]]T
+}
+
+func NewDeepSet[T any]() *DeepSet[T] {
+	return &DeepSet[T]{items: make(map[DeepHandle[T]]T)}
+}
+
+// Add adds value to the set, and reports whether it wasn't already there.
+func (s *DeepSet[T]) Add(value T) (bool, error) {
+	handle, err := Make(value)
+	if err != nil {
+		return false, err
+	}
+	return s.addHandle(handle, value), nil
+}
+
+func (s *DeepSet[T]) addHandle(handle DeepHandle[T], value T) bool {
+	if _, exists := s.items[handle]; exists {
+		return false
+	}
+	if s.items == nil {
+		s.items = make(map[DeepHandle[T]]T)
+	}
+	s.items[handle] = value
+	return true
+}
+
+// Contains reports whether a value deeply equal to value is in the set.
+func (s *DeepSet[T]) Contains(value T) (bool, error) {
+	handle, err := Make(value)
+	if err != nil {
+		return false, err
+	}
+	_, exists := s.items[handle]
+	return exists, nil
+}
+
+// Remove removes the value deeply equal to value, and reports whether there was one.
+func (s *DeepSet[T]) Remove(value T) (bool, error) {
+	handle, err := Make(value)
+	if err != nil {
+		return false, err
+	}
+	if _, exists := s.items[handle]; !exists {
+		return false, nil
+	}
+	delete(s.items, handle)
+	return true, nil
+}
+
+func (s *DeepSet[T]) Len() int {
+	return len(s.items)
+}
+
+// All returns an iterator over the values in the set, in no particular order.
+func (s *DeepSet[T]) All() iter.Seq[T] {
+	return maps.Values(s.items)
+}
+
+// Union returns a new set with the values in either s or other.
+func (s *DeepSet[T]) Union(other *DeepSet[T]) *DeepSet[T] {
+	result := &DeepSet[T]{items: maps.Clone(s.items)}
+	for handle, value := range other.items {
+		result.addHandle(handle, value)
+	}
+	return result
+}
+
+// Intersect returns a new set with the values in both s and other.
+func (s *DeepSet[T]) Intersect(other *DeepSet[T]) *DeepSet[T] {
+	result := NewDeepSet[T]()
+	for handle, value := range s.items {
+		if _, exists := other.items[handle]; exists {
+			result.items[handle] = value
+		}
+	}
+	return result
+}
+
+// Difference returns a new set with the values in s that aren't in other.
+func (s *DeepSet[T]) Difference(other *DeepSet[T]) *DeepSet[T] {
+	result := NewDeepSet[T]()
+	for handle, value := range s.items {
+		if _, exists := other.items[handle]; !exists {
+			result.items[handle] = value
+		}
+	}
+	return result
+}
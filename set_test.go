@@ -0,0 +1,126 @@
+// go_api/pkg/deepunique/set_test.go
+package deepunique
+
+import (
+	"slices"
+	"testing"
+)
+
+func mustAdd[T any](t *testing.T, s *DeepSet[T], values ...T) {
+	t.Helper()
+	for _, value := range values {
+		if _, err := s.Add(value); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+}
+
+func sortedPaths(s *DeepSet[[]string]) [][]string {
+	paths := slices.Collect(s.All())
+	slices.SortFunc(paths, slices.Compare)
+	return paths
+}
+
+func TestDeepSet(t *testing.T) {
+	var set DeepSet[[]string]
+
+	added, err := set.Add([]string{"spec", "ports"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !added {
+		t.Errorf("expected the first path to be added")
+	}
+	added, err = set.Add([]string{"spec", "ports"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if added {
+		t.Errorf("expected the duplicate path not to be added")
+	}
+	mustAdd(t, &set, []string{"spec", "labels"}, nil, []string{})
+
+	if set.Len() != 4 {
+		t.Errorf("expected 4 values, got %v", set.Len())
+	}
+
+	contains, err := set.Contains([]string{"spec", "labels"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !contains {
+		t.Errorf("expected the set to contain [spec labels]")
+	}
+
+	removed, err := set.Remove([]string{"spec", "labels"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !removed {
+		t.Errorf("expected [spec labels] to be removed")
+	}
+	removed, err = set.Remove([]string{"spec", "labels"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if removed {
+		t.Errorf("expected [spec labels] to be gone already")
+	}
+
+	contains, err = set.Contains([]string{"spec", "labels"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if contains {
+		t.Errorf("expected the set not to contain [spec labels]")
+	}
+	if set.Len() != 3 {
+		t.Errorf("expected 3 values, got %v", set.Len())
+	}
+}
+
+func TestDeepSetKeepsFirst(t *testing.T) {
+	// Shows that the set yields the first value added, like Unique.
+	alice := "Alice"
+	otherAlice := "Alice"
+
+	set := NewDeepSet[*string]()
+	mustAdd(t, set, &alice, &otherAlice)
+
+	for value := range set.All() {
+		if value != &alice {
+			t.Errorf("expected %v, got %v", &alice, value)
+		}
+	}
+}
+
+func TestDeepSetOperations(t *testing.T) {
+	a := NewDeepSet[[]string]()
+	mustAdd(t, a, []string{"a"}, []string{"b"}, []string{"c"})
+	b := NewDeepSet[[]string]()
+	mustAdd(t, b, []string{"b"}, []string{"c"}, []string{"d"})
+
+	tests := []struct {
+		name     string
+		result   *DeepSet[[]string]
+		expected [][]string
+	}{
+		{name: "Union", result: a.Union(b), expected: [][]string{{"a"}, {"b"}, {"c"}, {"d"}}},
+		{name: "Intersect", result: a.Intersect(b), expected: [][]string{{"b"}, {"c"}}},
+		{name: "Difference", result: a.Difference(b), expected: [][]string{{"a"}}},
+		{name: "Union with an empty set", result: new(DeepSet[[]string]).Union(a), expected: [][]string{{"a"}, {"b"}, {"c"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := sortedPaths(tt.result)
+			if !slices.EqualFunc(result, tt.expected, slices.Equal) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	if a.Len() != 3 || b.Len() != 3 {
+		t.Errorf("expected the operands to be unchanged, got %v and %v", a.Len(), b.Len())
+	}
+}
@@ -22,6 +22,10 @@ func (sbo indSort) Less(i, j int) bool {
 	return sbo.index[i] < sbo.index[j]
 }
 
+// SortMapTuples sorts items by the strings at the same positions in index.
+//
+// Deprecated: it was used to order map entries when encoding values as JSON, and
+// nothing in the package uses it anymore.
 func SortMapTuples(items [][2]any, index []string) {
 	ts := indSort{items, index}
 	sort.Sort(ts)
@@ -0,0 +1,165 @@
+package deepunique
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+)
+
+// A Store remembers fingerprints, so deduplication can carry on across calls and
+// across runs.
+type Store interface {
+	// SeenOrAdd reports whether fingerprint was already in the store, and adds
+	// it if it wasn't.
+	SeenOrAdd(fingerprint [32]byte) (bool, error)
+}
+
+// UniqueWithStore is like Unique, but compares items by Fingerprint and skips any
+// item whose fingerprint is already in store, including ones added by earlier
+// calls. The fingerprints of the items kept are added to store.
+//
+// Every item is fingerprinted before store is touched, so an item that can't be
+// fingerprinted leaves store as it was. If store itself fails partway, the items
+// kept before that are returned along with the error, since their fingerprints
+// have already been added and a later call would skip them.
+func UniqueWithStore[T any](items []T, store Store) ([]T, error) {
+	fingerprints := make([][32]byte, len(items))
+	for i, item := range items {
+		fingerprint, err := Fingerprint(item)
+		if err != nil {
+			return nil, err
+		}
+		fingerprints[i] = fingerprint
+	}
+
+	result := make([]T, 0, len(items))
+	for i, fingerprint := range fingerprints {
+		seen, err := store.SeenOrAdd(fingerprint)
+		if err != nil {
+			return result, err
+		}
+		if !seen {
+			result = append(result, items[i])
+		}
+	}
+	return result, nil
+}
+
+// MemoryStore is a Store that lives in memory. It is safe for concurrent use.
+type MemoryStore struct {
+	mu   sync.Mutex
+	seen map[[32]byte]struct{}
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{seen: make(map[[32]byte]struct{})}
+}
+
+func (s *MemoryStore) SeenOrAdd(fingerprint [32]byte) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.seen[fingerprint]; exists {
+		return true, nil
+	}
+	s.seen[fingerprint] = struct{}{}
+	return false, nil
+}
+
+// Each FileStore record is a fingerprint followed by its CRC-32, so a record that
+// was only partly written can be told apart from a complete one.
+const fileStoreRecordSize = 32 + 4
+
+// FileStore is a Store backed by an append-only file. All fingerprints are also
+// kept in memory. It is safe for concurrent use.
+//
+// If the process crashes in the middle of writing a record, the torn record is
+// truncated away the next time the file is opened.
+type FileStore struct {
+	mu   sync.Mutex
+	file *os.File
+	size int64
+	seen map[[32]byte]struct{}
+}
+
+// OpenFileStore opens the store at path, creating it if it doesn't exist.
+func OpenFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileStore{file: file, seen: make(map[[32]byte]struct{})}
+	if err := s.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads every complete record, then truncates anything after the last one.
+func (s *FileStore) load() error {
+	reader := bufio.NewReader(s.file)
+	var record [fileStoreRecordSize]byte
+	for {
+		if _, err := io.ReadFull(reader, record[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return err
+		}
+		var fingerprint [32]byte
+		copy(fingerprint[:], record[:32])
+		if crc32.ChecksumIEEE(fingerprint[:]) != binary.LittleEndian.Uint32(record[32:]) {
+			break
+		}
+		s.seen[fingerprint] = struct{}{}
+		s.size += fileStoreRecordSize
+	}
+
+	if err := s.file.Truncate(s.size); err != nil {
+		return err
+	}
+	_, err := s.file.Seek(s.size, io.SeekStart)
+	return err
+}
+
+func (s *FileStore) SeenOrAdd(fingerprint [32]byte) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.seen[fingerprint]; exists {
+		return true, nil
+	}
+
+	var record [fileStoreRecordSize]byte
+	copy(record[:32], fingerprint[:])
+	binary.LittleEndian.PutUint32(record[32:], crc32.ChecksumIEEE(fingerprint[:]))
+	if _, err := s.file.Write(record[:]); err != nil {
+		// Don't leave a torn record in front of the next one.
+		if truncErr := s.file.Truncate(s.size); truncErr != nil {
+			return false, errors.Join(err, truncErr)
+		}
+		if _, seekErr := s.file.Seek(s.size, io.SeekStart); seekErr != nil {
+			return false, errors.Join(err, seekErr)
+		}
+		return false, err
+	}
+	s.size += fileStoreRecordSize
+	s.seen[fingerprint] = struct{}{}
+	return false, nil
+}
+
+// Sync commits the records written so far to stable storage.
+func (s *FileStore) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Sync()
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
@@ -0,0 +1,185 @@
+// go_api/pkg/deepunique/store_test.go
+package deepunique
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type storeRecord struct {
+	ID   int
+	Tags []string
+}
+
+func openTestStore(t *testing.T, path string) *FileStore {
+	t.Helper()
+	store, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return store
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+
+	first, err := UniqueWithStore([]storeRecord{{ID: 1}, {ID: 2}, {ID: 1}}, store)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(first) != 2 {
+		t.Errorf("expected length %v, got %v", 2, len(first))
+	}
+
+	second, err := UniqueWithStore([]storeRecord{{ID: 2}, {ID: 3}}, store)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(second) != 1 || second[0].ID != 3 {
+		t.Errorf("expected only ID 3, got %v", second)
+	}
+}
+
+func TestFileStoreReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen")
+
+	store := openTestStore(t, path)
+	first, err := UniqueWithStore([]storeRecord{{ID: 1, Tags: []string{"a"}}, {ID: 2}}, store)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(first) != 2 {
+		t.Errorf("expected length %v, got %v", 2, len(first))
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// The next run skips what the first one already saw.
+	store = openTestStore(t, path)
+	defer store.Close()
+	second, err := UniqueWithStore([]storeRecord{{ID: 1, Tags: []string{"a"}}, {ID: 3}, {ID: 2}}, store)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(second) != 1 || second[0].ID != 3 {
+		t.Errorf("expected only ID 3, got %v", second)
+	}
+}
+
+func TestFileStoreTornTail(t *testing.T) {
+	tests := []struct {
+		name string
+		tail []byte
+	}{
+		{name: "Partial record", tail: make([]byte, fileStoreRecordSize/2)},
+		{name: "Record with a bad checksum", tail: make([]byte, fileStoreRecordSize)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "seen")
+			fingerprint, err := Fingerprint(storeRecord{ID: 1})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			store := openTestStore(t, path)
+			if _, err := store.SeenOrAdd(fingerprint); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			store.Close()
+
+			// Simulate a crash in the middle of writing the next record.
+			tt.tail[len(tt.tail)-1] = 1
+			file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			file.Write(tt.tail)
+			file.Close()
+
+			store = openTestStore(t, path)
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if info.Size() != fileStoreRecordSize {
+				t.Errorf("expected the torn record to be truncated, got size %v", info.Size())
+			}
+			seen, err := store.SeenOrAdd(fingerprint)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !seen {
+				t.Errorf("expected the complete record to survive")
+			}
+
+			// New records go after the last complete one.
+			otherFingerprint, err := Fingerprint(storeRecord{ID: 2})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if _, err := store.SeenOrAdd(otherFingerprint); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			store.Close()
+
+			store = openTestStore(t, path)
+			defer store.Close()
+			for _, fp := range [][32]byte{fingerprint, otherFingerprint} {
+				seen, err := store.SeenOrAdd(fp)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if !seen {
+					t.Errorf("expected %x to be seen after reopening", fp)
+				}
+			}
+		})
+	}
+}
+
+// failingStore fails once it has been asked about limit fingerprints.
+type failingStore struct {
+	Store
+	limit int
+}
+
+func (s *failingStore) SeenOrAdd(fingerprint [32]byte) (bool, error) {
+	if s.limit == 0 {
+		return false, errors.New("disk full")
+	}
+	s.limit--
+	return s.Store.SeenOrAdd(fingerprint)
+}
+
+func TestUniqueWithStoreErrors(t *testing.T) {
+	// Shows that items that can't be fingerprinted return an error without adding
+	// anything to the store.
+	store := NewMemoryStore()
+	items := []any{storeRecord{ID: 1}, storeRecord{ID: 2}, make(chan int)}
+	if _, err := UniqueWithStore(items, store); err == nil {
+		t.Errorf("expected an error")
+	}
+	result, err := UniqueWithStore(items[:2], store)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(result, items[:2]) {
+		t.Errorf("expected %v, got %v", items[:2], result)
+	}
+
+	// Shows that when the store fails, the items already added are returned.
+	records := []storeRecord{{ID: 1}, {ID: 1}, {ID: 2}, {ID: 3}}
+	kept, err := UniqueWithStore(records, &failingStore{Store: NewMemoryStore(), limit: 3})
+	if err == nil {
+		t.Errorf("expected an error")
+	}
+	if expected := []storeRecord{records[0], records[2]}; !reflect.DeepEqual(kept, expected) {
+		t.Errorf("expected %v, got %v", expected, kept)
+	}
+}
@@ -0,0 +1,74 @@
+package deepunique
+
+import (
+	"context"
+	"iter"
+)
+
+// UniqueSeq returns a sequence of the first occurrence of each deeply-equal item
+// in seq, in order, without materializing seq. Items are canonicalized as they
+// are pulled.
+//
+// An item that can't be canonicalized is yielded along with the error, and the
+// consumer can decide whether to stop.
+func UniqueSeq[T any](seq iter.Seq[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		seen := newSeenKeys[T](defaultOptions)
+		for item := range seq {
+			_, first, err := seen.add(item)
+			if err != nil {
+				if !yield(item, err) {
+					return
+				}
+				continue
+			}
+			if first && !yield(item, nil) {
+				return
+			}
+		}
+	}
+}
+
+// UniqueChan starts a pipeline stage that forwards the first occurrence of each
+// deeply-equal item from in, in order. The returned channel is closed once in is
+// closed or ctx is done.
+//
+// Items that can't be canonicalized are passed to onError, if it isn't nil, and
+// are not forwarded.
+func UniqueChan[T any](ctx context.Context, in <-chan T, onError func(T, error)) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		seen := newSeenKeys[T](defaultOptions)
+		for {
+			var item T
+			select {
+			case <-ctx.Done():
+				return
+			case received, ok := <-in:
+				if !ok {
+					return
+				}
+				item = received
+			}
+
+			_, first, err := seen.add(item)
+			if err != nil {
+				if onError != nil {
+					onError(item, err)
+				}
+				continue
+			}
+			if !first {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- item:
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,124 @@
+// go_api/pkg/deepunique/stream_test.go
+package deepunique
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+type streamRecord struct {
+	ID   int
+	Tags []string
+}
+
+var streamRecords = []streamRecord{
+	{ID: 1, Tags: []string{"a"}},
+	{ID: 2, Tags: []string{"b"}},
+	{ID: 1, Tags: []string{"a"}},
+	{ID: 1, Tags: []string{"a", "b"}},
+	{ID: 2, Tags: []string{"b"}},
+}
+
+func TestUniqueSeq(t *testing.T) {
+	var result []streamRecord
+	for record, err := range UniqueSeq(slices.Values(streamRecords)) {
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		result = append(result, record)
+	}
+
+	expected, err := Unique(streamRecords)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !slices.EqualFunc(result, expected, func(a, b streamRecord) bool {
+		return a.ID == b.ID && slices.Equal(a.Tags, b.Tags)
+	}) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
+
+func TestUniqueSeqLazy(t *testing.T) {
+	// Shows that items are only pulled as the consumer asks for them.
+	pulled := 0
+	seq := func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			pulled++
+			if !yield(i % 3) {
+				return
+			}
+		}
+	}
+
+	var result []int
+	for item, err := range UniqueSeq(seq) {
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		result = append(result, item)
+		if len(result) == 3 {
+			break
+		}
+	}
+
+	if !slices.Equal(result, []int{0, 1, 2}) {
+		t.Errorf("expected %v, got %v", []int{0, 1, 2}, result)
+	}
+	if pulled != 3 {
+		t.Errorf("expected 3 items pulled, got %v", pulled)
+	}
+}
+
+func TestUniqueChan(t *testing.T) {
+	in := make(chan streamRecord)
+	go func() {
+		defer close(in)
+		for _, record := range streamRecords {
+			in <- record
+		}
+	}()
+
+	var result []streamRecord
+	out := UniqueChan(context.Background(), in, func(record streamRecord, err error) {
+		t.Errorf("expected no error for %v, got %v", record, err)
+	})
+	for record := range out {
+		result = append(result, record)
+	}
+
+	expectedIDs := []int{1, 2, 1}
+	if len(result) != len(expectedIDs) {
+		t.Fatalf("expected length %v, got %v", len(expectedIDs), len(result))
+	}
+	for i := range result {
+		if result[i].ID != expectedIDs[i] {
+			t.Errorf("expected IDs %v, got %v", expectedIDs, result)
+			break
+		}
+	}
+}
+
+func TestUniqueChanCancel(t *testing.T) {
+	// Shows that the output is closed when the context is done, even if the input isn't.
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan int)
+	out := UniqueChan(ctx, in, nil)
+
+	in <- 1
+	if item := <-out; item != 1 {
+		t.Errorf("expected %v, got %v", 1, item)
+	}
+	cancel()
+
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Errorf("expected the output to be closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected the output to be closed after cancel")
+	}
+}
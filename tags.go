@@ -0,0 +1,227 @@
+package deepunique
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Struct fields can change how they are compared with a `deepunique` tag, a
+// comma-separated list of:
+//
+//   - "-" leaves the field out of equality.
+//   - "ptr" compares a pointer, map or channel field by address instead of by value.
+//   - "set" compares a slice or array field as a multiset, ignoring the order of its
+//     elements, along with the slices nested in it, like UnorderedFields.
+//   - "ci" compares the strings in the field case-insensitively, like strings.EqualFold.
+//     The field has to be able to hold strings other than map keys.
+//
+// Tags apply wherever the struct type is encoded, including in Diff and
+// Fingerprint, but not to map keys, which are still compared with ==. A tag that
+// can't be used makes encoding fail with an *UnsupportedValueError.
+type fieldTag uint8
+
+const (
+	tagSkip fieldTag = 1 << iota
+	tagPtr
+	tagSet
+	tagFold
+)
+
+type structField struct {
+	name     string
+	exported bool
+	tag      fieldTag
+	// err says why the tag can't be used, if it can't.
+	err string
+}
+
+type structInfo struct {
+	fields []structField
+	// tagged is set if any field has a tag.
+	tagged bool
+}
+
+var structInfos sync.Map // reflect.Type -> *structInfo
+
+func structInfoOf(t reflect.Type) *structInfo {
+	if info, ok := structInfos.Load(t); ok {
+		return info.(*structInfo)
+	}
+	info := &structInfo{fields: make([]structField, t.NumField())}
+	for i := range info.fields {
+		field := t.Field(i)
+		tag, err := parseTag(field)
+		info.fields[i] = structField{name: field.Name, exported: field.IsExported(), tag: tag, err: err}
+		info.tagged = info.tagged || tag != 0 || err != ""
+	}
+	actual, _ := structInfos.LoadOrStore(t, info)
+	return actual.(*structInfo)
+}
+
+func parseTag(field reflect.StructField) (fieldTag, string) {
+	value := field.Tag.Get("deepunique")
+	if value == "" {
+		return 0, ""
+	}
+	var tag fieldTag
+	for option := range strings.SplitSeq(value, ",") {
+		switch option {
+		case "-":
+			tag |= tagSkip
+		case "ptr":
+			tag |= tagPtr
+		case "set":
+			tag |= tagSet
+		case "ci":
+			tag |= tagFold
+		default:
+			return 0, fmt.Sprintf("unknown deepunique tag option %q on field %s", option, field.Name)
+		}
+	}
+
+	switch kind := field.Type.Kind(); {
+	case tag&tagSkip != 0:
+		return tagSkip, ""
+	case tag&tagPtr != 0 && tag&tagSet != 0:
+		return 0, fmt.Sprintf("deepunique tag on field %s can't have both ptr and set", field.Name)
+	case tag&tagPtr != 0 && kind != reflect.Pointer && kind != reflect.Map && kind != reflect.Chan && kind != reflect.UnsafePointer:
+		return 0, fmt.Sprintf("deepunique:\"ptr\" needs a pointer, map or channel, but field %s is a %v", field.Name, kind)
+	case tag&tagSet != 0 && kind != reflect.Slice && kind != reflect.Array:
+		return 0, fmt.Sprintf("deepunique:\"set\" needs a slice or array, but field %s is a %v", field.Name, kind)
+	case tag&tagPtr != 0 && tag&tagFold != 0:
+		return 0, fmt.Sprintf("deepunique tag on field %s can't have both ptr and ci", field.Name)
+	case tag&tagFold != 0 && !holdsStrings(field.Type, make(map[reflect.Type]bool)):
+		return 0, fmt.Sprintf("deepunique:\"ci\" needs a type that holds strings, but field %s is a %v", field.Name, field.Type)
+	}
+	return tag, ""
+}
+
+// holdsStrings reports whether values of type t can have strings in them that ci
+// would fold. Map keys don't count, since they aren't folded.
+func holdsStrings(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	switch t.Kind() {
+	case reflect.String, reflect.Interface:
+		return true
+	case reflect.Array, reflect.Slice, reflect.Pointer, reflect.Map:
+		return holdsStrings(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if holdsStrings(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// encodeField writes a struct field the way its tag says to.
+func (e *encoder) encodeField(value reflect.Value, field *structField) {
+	switch {
+	case field.err != "":
+		e.fail(value, field.err)
+		return
+	case field.tag == 0:
+		e.encode(value)
+		return
+	}
+
+	fold, unordered := e.fold, e.unordered
+	if field.tag&tagFold != 0 {
+		e.fold = true
+	}
+	switch {
+	case field.tag&tagPtr != 0:
+		if value.IsNil() {
+			e.writeByte(markNil)
+		} else {
+			e.writeByte(markValue)
+			e.writeIdentity(value)
+		}
+	case field.tag&tagSet != 0:
+		// The field itself is a set even if it's a byte slice.
+		e.unordered = true
+		e.encodeSet(value, typeEncoder(value.Type().Elem()))
+	default:
+		e.encode(value)
+	}
+	e.fold, e.unordered = fold, unordered
+}
+
+type setElement struct {
+	encoding []byte
+	equals   []equalValue
+}
+
+// encodeSet writes a slice or array as a multiset, by sorting the encodings of
+// its elements. The encodings are prefix-free, so the sorted concatenation is
+// equal exactly when the elements are equal up to order.
+//
+// Values left for Equal methods are reordered along with their elements.
+// Elements whose encodings are the same are kept in order, so they are only
+// matched up with Equal in that order.
+func (e *encoder) encodeSet(value reflect.Value, elem encoderFunc) {
+	if value.Kind() == reflect.Slice {
+		if value.IsNil() && !e.opts.nilEqualsEmpty {
+			e.writeByte(markNil)
+			return
+		}
+		if value.Len() == 0 {
+			e.writeByte(markValue)
+			e.writeUvarint(0)
+			return
+		}
+		if !e.enterPath(value) {
+			return
+		}
+		defer e.leavePath()
+		e.writeByte(markValue)
+		e.writeUvarint(uint64(value.Len()))
+	}
+
+	buf := e.buf
+	equals := len(e.equals)
+	elements := make([]setElement, value.Len())
+	for i := range elements {
+		start := len(e.equals)
+		e.buf = nil
+		elem(e, value.Index(i))
+		if e.err != nil {
+			e.buf = buf
+			e.failedAt(fmt.Sprintf("[%d]", i))
+			return
+		}
+		elements[i] = setElement{encoding: e.buf, equals: slices.Clone(e.equals[start:])}
+	}
+	slices.SortStableFunc(elements, func(a, b setElement) int {
+		return bytes.Compare(a.encoding, b.encoding)
+	})
+
+	e.buf = buf
+	e.equals = e.equals[:equals]
+	for _, element := range elements {
+		e.buf = append(e.buf, element.encoding...)
+		e.equals = append(e.equals, element.equals...)
+	}
+}
+
+// foldString maps each rune of s to the smallest rune it is case-insensitively
+// equal to, so two strings fold the same exactly when strings.EqualFold says
+// they're equal.
+func foldString(s string) string {
+	return strings.Map(func(r rune) rune {
+		folded := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			folded = min(folded, f)
+		}
+		return folded
+	}, s)
+}
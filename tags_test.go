@@ -0,0 +1,220 @@
+// go_api/pkg/deepunique/tags_test.go
+package deepunique
+
+import (
+	"errors"
+	"testing"
+)
+
+type taggedOwner struct {
+	Name string
+}
+
+type taggedResource struct {
+	Name            string       `deepunique:"ci"`
+	ResourceVersion int          `deepunique:"-"`
+	Owner           *taggedOwner `deepunique:"ptr"`
+	Labels          []string     `deepunique:"set"`
+	Aliases         []string     `deepunique:"set,ci"`
+	Groups          [][]string   `deepunique:"set"`
+	Ports           [3]int       `deepunique:"set"`
+	Parent          *taggedResource
+}
+
+func TestStructTags(t *testing.T) {
+	owner := &taggedOwner{Name: "team"}
+	otherOwner := &taggedOwner{Name: "team"}
+	ring := &taggedResource{Name: "ring"}
+	ring.Parent = ring
+	otherRing := &taggedResource{Name: "RING"}
+	otherRing.Parent = otherRing
+
+	tests := []struct {
+		name     string
+		a        any
+		b        any
+		expected bool
+	}{
+		{name: "Skipped field", a: taggedResource{ResourceVersion: 1}, b: taggedResource{ResourceVersion: 2}, expected: true},
+		{name: "Case-insensitive", a: taggedResource{Name: "Straße"}, b: taggedResource{Name: "STRASSE"}, expected: false},
+		{name: "Case-insensitive fold", a: taggedResource{Name: "Kelvin"}, b: taggedResource{Name: "KELVIN"}, expected: true},
+		{name: "Case-insensitive different", a: taggedResource{Name: "api"}, b: taggedResource{Name: "apis"}, expected: false},
+		{name: "Same pointer", a: taggedResource{Owner: owner}, b: taggedResource{Owner: owner}, expected: true},
+		{name: "Different pointers to same value", a: taggedResource{Owner: owner}, b: taggedResource{Owner: otherOwner}, expected: false},
+		{name: "Nil and non-nil pointer", a: taggedResource{}, b: taggedResource{Owner: owner}, expected: false},
+		{name: "Set order", a: taggedResource{Labels: []string{"a", "b", "a"}}, b: taggedResource{Labels: []string{"b", "a", "a"}}, expected: true},
+		{name: "Set multiplicity", a: taggedResource{Labels: []string{"a", "b", "b"}}, b: taggedResource{Labels: []string{"b", "a", "a"}}, expected: false},
+		{name: "Set nil and empty", a: taggedResource{Labels: nil}, b: taggedResource{Labels: []string{}}, expected: false},
+		{name: "Case-insensitive set", a: taggedResource{Aliases: []string{"Web", "API"}}, b: taggedResource{Aliases: []string{"api", "web"}}, expected: true},
+		{name: "Array set", a: taggedResource{Ports: [3]int{443, 80, 80}}, b: taggedResource{Ports: [3]int{80, 443, 80}}, expected: true},
+		{name: "Nested sets", a: taggedResource{Groups: [][]string{{"a", "b"}, {"c"}}}, b: taggedResource{Groups: [][]string{{"c"}, {"b", "a"}}}, expected: true},
+		{name: "Nested tags", a: []taggedResource{{Name: "A", ResourceVersion: 1}}, b: []taggedResource{{Name: "a", ResourceVersion: 2}}, expected: true},
+		{name: "Tags in a cycle", a: ring, b: otherRing, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handleA, err := Make(tt.a)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			handleB, err := Make(tt.b)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if (handleA == handleB) != tt.expected {
+				t.Errorf("expected equal handles to be %v, got %v", tt.expected, handleA == handleB)
+			}
+			if diffs := Diff(tt.a, tt.b); (len(diffs) == 0) != tt.expected {
+				t.Errorf("expected Diff to agree with Make, got %v", diffs)
+			}
+		})
+	}
+}
+
+func TestStructTagsDiff(t *testing.T) {
+	a := taggedResource{Name: "api", ResourceVersion: 1, Labels: []string{"a", "b"}}
+	b := taggedResource{Name: "API", ResourceVersion: 2, Labels: []string{"b", "c"}}
+
+	diffs := Diff(a, b)
+	if len(diffs) != 1 || diffs[0].Path != ".Labels" || diffs[0].Reason != ValueMismatch {
+		t.Errorf("expected a value mismatch at .Labels, got %v", diffs)
+	}
+}
+
+func TestStructTagErrors(t *testing.T) {
+	type unknownOption struct {
+		Name string `deepunique:"lower"`
+	}
+	type ptrOnString struct {
+		Name string `deepunique:"ptr"`
+	}
+	type setOnMap struct {
+		Labels map[string]string `deepunique:"set"`
+	}
+	type nested struct {
+		Items []setOnMap
+	}
+	type ciOnInt struct {
+		Count int `deepunique:"ci"`
+	}
+	type ciOnIntKeys struct {
+		Counts map[string]int `deepunique:"ci"`
+	}
+	type ptrAndCI struct {
+		Name *string `deepunique:"ptr,ci"`
+	}
+
+	tests := []struct {
+		name  string
+		value any
+		path  string
+	}{
+		{name: "Unknown option", value: unknownOption{}, path: ".Name"},
+		{name: "Ptr on a string", value: ptrOnString{}, path: ".Name"},
+		{name: "Set on a map", value: setOnMap{}, path: ".Labels"},
+		{name: "Nested", value: nested{Items: []setOnMap{{}}}, path: ".Items[0].Labels"},
+		{name: "Ci on an int", value: ciOnInt{}, path: ".Count"},
+		{name: "Ci on a map with only string keys", value: ciOnIntKeys{}, path: ".Counts"},
+		{name: "Ptr and ci", value: ptrAndCI{}, path: ".Name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Make(tt.value)
+			var unsupported *UnsupportedValueError
+			if !errors.As(err, &unsupported) {
+				t.Fatalf("expected an UnsupportedValueError, got %v", err)
+			}
+			if unsupported.Path != tt.path {
+				t.Errorf("expected %v, got %v", tt.path, unsupported.Path)
+			}
+		})
+	}
+}
+
+func TestStructTagsFingerprint(t *testing.T) {
+	a, err := Fingerprint(taggedResource{Name: "API", ResourceVersion: 1, Labels: []string{"b", "a"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	b, err := Fingerprint(taggedResource{Name: "api", ResourceVersion: 2, Labels: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if a != b {
+		t.Errorf("expected equal fingerprints, got %x and %x", a, b)
+	}
+
+	if _, err := Fingerprint(taggedResource{Owner: &taggedOwner{}}); err == nil {
+		t.Errorf("expected an error for a field compared by address")
+	}
+}
+
+func TestStructTagCaseInsensitiveMapKeys(t *testing.T) {
+	// Shows that ci folds map values but not keys, which are still compared with ==.
+	type labels struct {
+		M map[string]string `deepunique:"ci"`
+	}
+
+	value := labels{M: map[string]string{"A": "x", "a": "y", "b": "z"}}
+	handle, err := Make(value)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	fingerprint, err := Fingerprint(value)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for range 100 {
+		otherHandle, err := Make(labels{M: map[string]string{"b": "z", "a": "y", "A": "x"}})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if otherHandle != handle {
+			t.Fatalf("expected %v, got %v", handle, otherHandle)
+		}
+		otherFingerprint, err := Fingerprint(value)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if otherFingerprint != fingerprint {
+			t.Fatalf("expected %x, got %x", fingerprint, otherFingerprint)
+		}
+	}
+
+	result, err := Unique([]labels{value, value, value, value})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result) != 1 {
+		t.Errorf("expected 1 item, got %v", len(result))
+	}
+
+	tests := []struct {
+		name     string
+		a        labels
+		b        labels
+		expected bool
+	}{
+		{name: "Values differ by case", a: labels{M: map[string]string{"k": "V"}}, b: labels{M: map[string]string{"k": "v"}}, expected: true},
+		{name: "Keys differ by case", a: labels{M: map[string]string{"K": "v"}}, b: labels{M: map[string]string{"k": "v"}}, expected: false},
+		{name: "Swapped values of keys that differ by case", a: labels{M: map[string]string{"A": "x", "a": "y"}}, b: labels{M: map[string]string{"A": "y", "a": "x"}}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handleA, err := Make(tt.a)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			handleB, err := Make(tt.b)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if (handleA == handleB) != tt.expected {
+				t.Errorf("expected equal handles to be %v, got %v", tt.expected, handleA == handleB)
+			}
+		})
+	}
+}